@@ -0,0 +1,250 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"go.uber.org/zap"
+)
+
+// Task batch task of an imagor path, or a manifest entry of source, params and destination
+type Task struct {
+	Path        string `json:"path,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Params      string `json:"params,omitempty"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// Failure failed batch task with error
+type Failure struct {
+	Task
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// Report batch run report
+type Report struct {
+	Total     int       `json:"total"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	Duration  string    `json:"duration"`
+	Failures  []Failure `json:"failures"`
+}
+
+// Batch runs imagor tasks with bounded concurrency,
+// writes outputs to directory or storages
+type Batch struct {
+	App            *imagor.Imagor
+	Concurrency    int
+	OutputDir      string
+	OutputStorages []imagor.Storage
+	Logger         *zap.Logger
+}
+
+// New create new Batch
+func New(app *imagor.Imagor, options ...Option) *Batch {
+	b := &Batch{
+		App:         app,
+		Concurrency: runtime.NumCPU(),
+		Logger:      zap.NewNop(),
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// ReadTasks reads tasks from reader line by line.
+// Each line is either an imagor path, or a JSON manifest entry of Task.
+// Empty lines and lines starting with # are skipped
+func ReadTasks(r io.Reader) (tasks []Task, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var task Task
+		if strings.HasPrefix(line, "{") {
+			if err = json.Unmarshal([]byte(line), &task); err != nil {
+				return
+			}
+		} else {
+			task.Path = line
+		}
+		tasks = append(tasks, task)
+	}
+	err = scanner.Err()
+	return
+}
+
+// ImagorParams returns imagor params of the task
+func (t Task) ImagorParams() imagorpath.Params {
+	return t.ParamsWithPresets(nil)
+}
+
+// ParamsWithPresets returns imagor params of the task, expanding preset reference of presets
+func (t Task) ParamsWithPresets(presets imagorpath.Presets) imagorpath.Params {
+	if t.Path != "" {
+		return presets.Parse(t.Path)
+	}
+	params := strings.Trim(t.Params, "/")
+	if params == "" {
+		return presets.Parse(t.Source)
+	}
+	return presets.Parse(params + "/" + t.Source)
+}
+
+// Run runs tasks with bounded concurrency and returns the report
+func (b *Batch) Run(ctx context.Context, tasks []Task) *Report {
+	var (
+		start    = time.Now()
+		report   = &Report{Total: len(tasks), Failures: []Failure{}}
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		ch       = make(chan Task)
+		nWorkers = b.Concurrency
+	)
+	if nWorkers < 1 {
+		nWorkers = 1
+	}
+	for i := 0; i < nWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range ch {
+				err := b.do(ctx, task)
+				mu.Lock()
+				if err != nil {
+					report.Failures = append(report.Failures, Failure{
+						Task:   task,
+						Error:  err.Error(),
+						Status: imagor.WrapError(err).Code,
+					})
+					b.Logger.Warn("batch", zap.Any("task", task), zap.Error(err))
+				} else {
+					report.Succeeded++
+					b.Logger.Debug("batch", zap.Any("task", task))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, task := range tasks {
+		select {
+		case ch <- task:
+		case <-ctx.Done():
+			mu.Lock()
+			report.Failures = append(report.Failures, Failure{
+				Task: task, Error: ctx.Err().Error(), Status: imagor.WrapError(ctx.Err()).Code,
+			})
+			mu.Unlock()
+		}
+	}
+	close(ch)
+	wg.Wait()
+	report.Failed = len(report.Failures)
+	report.Duration = time.Since(start).String()
+	return report
+}
+
+func (b *Batch) do(ctx context.Context, task Task) error {
+	p := task.ParamsWithPresets(b.App.Presets)
+	if _, ok := b.App.Presets.Unresolved(p); ok || p.Image == "" {
+		return imagor.ErrInvalid
+	}
+	// wait for result storage save, which otherwise continues after response
+	blob, err := b.App.Serve(imagor.WithWaitSave(ctx), p)
+	if err != nil {
+		return err
+	}
+	if b.OutputDir == "" && len(b.OutputStorages) == 0 {
+		return nil
+	}
+	key := task.Destination
+	if key == "" {
+		key = imagorpath.GeneratePath(p)
+		if b.App.ResultStoragePathStyle != nil {
+			key = b.App.ResultStoragePathStyle.HashResult(p)
+		}
+	}
+	if b.OutputDir != "" {
+		if err := writeFile(filepath.Join(b.OutputDir, filepath.Clean("/"+key)), blob); err != nil {
+			return err
+		}
+	}
+	for _, storage := range b.OutputStorages {
+		if err := storage.Put(ctx, key, blob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, blob *imagor.Blob) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	reader, _, err := blob.NewReader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	w, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Option Batch option
+type Option func(b *Batch)
+
+// WithConcurrency with maximum number of tasks run simultaneously option
+func WithConcurrency(concurrency int) Option {
+	return func(b *Batch) {
+		if concurrency > 0 {
+			b.Concurrency = concurrency
+		}
+	}
+}
+
+// WithOutputDir with output directory option
+func WithOutputDir(dir string) Option {
+	return func(b *Batch) {
+		b.OutputDir = dir
+	}
+}
+
+// WithOutputStorages with output storages option
+func WithOutputStorages(storages ...imagor.Storage) Option {
+	return func(b *Batch) {
+		b.OutputStorages = append(b.OutputStorages, storages...)
+	}
+}
+
+// WithLogger with logger option
+func WithLogger(logger *zap.Logger) Option {
+	return func(b *Batch) {
+		if logger != nil {
+			b.Logger = logger
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package batch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/storage/memorystorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type loaderFunc func(r *http.Request, image string) (blob *imagor.Blob, err error)
+
+func (f loaderFunc) Get(r *http.Request, image string) (*imagor.Blob, error) {
+	return f(r, image)
+}
+
+func TestReadTasks(t *testing.T) {
+	tasks, err := ReadTasks(strings.NewReader(`
+# comment
+/unsafe/fit-in/200x200/foo.jpg
+  200x0/bar.jpg  
+{"source":"baz.jpg","params":"fit-in/100x100/","destination":"out/baz.jpg"}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []Task{
+		{Path: "/unsafe/fit-in/200x200/foo.jpg"},
+		{Path: "200x0/bar.jpg"},
+		{Source: "baz.jpg", Params: "fit-in/100x100/", Destination: "out/baz.jpg"},
+	}, tasks)
+
+	p := tasks[2].ImagorParams()
+	assert.Equal(t, "baz.jpg", p.Image)
+	assert.True(t, p.FitIn)
+	assert.Equal(t, 100, p.Width)
+	assert.Equal(t, "baz.jpg", Task{Source: "baz.jpg"}.ImagorParams().Image)
+
+	_, err = ReadTasks(strings.NewReader(`{"source":`))
+	assert.Error(t, err)
+}
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	store := memorystorage.New(1 << 20)
+	app := imagor.New(imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+		if strings.HasPrefix(image, "missing") {
+			return nil, imagor.ErrNotFound
+		}
+		return imagor.NewBlobFromBytes([]byte(image)), nil
+	})))
+	b := New(app,
+		WithConcurrency(2),
+		WithOutputDir(dir),
+		WithOutputStorages(store),
+		WithLogger(zap.NewNop()),
+	)
+	assert.Equal(t, 2, b.Concurrency)
+
+	report := b.Run(context.Background(), []Task{
+		{Path: "/unsafe/fit-in/200x200/foo.jpg"},
+		{Source: "bar.jpg", Params: "100x100", Destination: "../../out/bar.jpg"},
+		{Path: "missing.jpg"},
+		{Path: "/unsafe/"},
+	})
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, 2, report.Failed)
+	assert.NotEmpty(t, report.Duration)
+	assert.ElementsMatch(t, []Failure{
+		{Task: Task{Path: "missing.jpg"}, Error: imagor.ErrNotFound.Error(), Status: http.StatusNotFound},
+		{Task: Task{Path: "/unsafe/"}, Error: imagor.ErrInvalid.Error(), Status: http.StatusBadRequest},
+	}, report.Failures)
+
+	buf, err := os.ReadFile(filepath.Join(dir, "fit-in/200x200/foo.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo.jpg", string(buf))
+	buf, err = os.ReadFile(filepath.Join(dir, "out/bar.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "bar.jpg", string(buf))
+
+	blob, err := store.Get(httptest.NewRequest(http.MethodGet, "/", nil), "fit-in/200x200/foo.jpg")
+	require.NoError(t, err)
+	buf, err = blob.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "foo.jpg", string(buf))
+}
+
+type slowStorage struct {
+	*memorystorage.MemoryStorage
+}
+
+func (s slowStorage) Put(ctx context.Context, key string, blob *imagor.Blob) error {
+	time.Sleep(time.Millisecond * 50)
+	return s.MemoryStorage.Put(ctx, key, blob)
+}
+
+func TestBatchResultStorage(t *testing.T) {
+	resultStore := memorystorage.New(1 << 20)
+	app := imagor.New(
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return imagor.NewBlobFromBytes([]byte(image)), nil
+		})),
+		imagor.WithResultStorages(slowStorage{resultStore}),
+	)
+	report := New(app).Run(context.Background(), []Task{
+		{Path: "fit-in/200x200/foo.jpg"},
+		{Path: "100x100/bar.jpg"},
+	})
+	assert.Equal(t, 2, report.Succeeded)
+	// result storage saved by the time Run returns
+	for _, key := range []string{"fit-in/200x200/foo.jpg", "100x100/bar.jpg"} {
+		_, err := resultStore.Stat(context.Background(), key)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestBatchPresets(t *testing.T) {
+	dir := t.TempDir()
+	app := imagor.New(
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return imagor.NewBlobFromBytes([]byte(image)), nil
+		})),
+		imagor.WithPresets(map[string]string{"thumb": "fit-in/150x150"}),
+	)
+	report := New(app, WithOutputDir(dir)).Run(context.Background(), []Task{
+		{Path: "p/thumb/foo.jpg"},
+		{Source: "bar.jpg", Params: "preset:thumb/filters:format(webp)"},
+		{Path: "p/typo/baz.jpg"},
+	})
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, []Failure{
+		{Task: Task{Path: "p/typo/baz.jpg"}, Error: imagor.ErrInvalid.Error(), Status: http.StatusBadRequest},
+	}, report.Failures)
+	for _, key := range []string{"fit-in/150x150/foo.jpg", "fit-in/150x150/filters:format(webp)/bar.jpg"} {
+		_, err := os.Stat(filepath.Join(dir, key))
+		assert.NoError(t, err, key)
+	}
+	p := Task{Path: "p/thumb/foo.jpg"}.ParamsWithPresets(app.Presets)
+	assert.True(t, p.FitIn)
+	assert.Equal(t, 150, p.Width)
+	assert.Equal(t, "foo.jpg", p.Image)
+}
+
+func TestBatchCanceled(t *testing.T) {
+	app := imagor.New(imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+		return imagor.NewBlobFromBytes([]byte(image)), nil
+	})))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := New(app).Run(ctx, []Task{{Path: "foo.jpg"}, {Path: "bar.jpg"}})
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, report.Total, report.Succeeded+report.Failed)
+	assert.NotZero(t, report.Failed)
+}
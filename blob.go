@@ -36,6 +36,9 @@ const (
 	BlobTypeBMP
 	BlobTypePDF
 	BlobTypeSVG
+	BlobTypeJXL
+	BlobTypeMP4
+	BlobTypeWEBM
 )
 
 // Blob imagor data blob abstraction
@@ -171,6 +174,19 @@ var jpx = []byte{0x6a, 0x70, 0x78, 0x20}
 // Jpm matches a JPEG 2000 Image file (ISO 15444-6).
 var jpm = []byte{0x6a, 0x70, 0x6D, 0x20}
 
+// JPEG XL naked codestream and ISOBMFF based container
+var jxlCodestream = []byte{0xFF, 0x0A}
+var jxlContainer = []byte{0x00, 0x00, 0x00, 0x0C, 0x4A, 0x58, 0x4C, 0x20, 0x0D, 0x0A, 0x87, 0x0A}
+
+// ISO base media brands of MP4 video
+var mp4Brands = [][]byte{
+	[]byte("isom"), []byte("iso2"), []byte("iso4"), []byte("iso5"), []byte("iso6"),
+	[]byte("mp41"), []byte("mp42"), []byte("avc1"), []byte("M4V "), []byte("dash"),
+}
+
+// EBML header of Matroska and WebM video
+var webmHeader = []byte("\x1A\x45\xDF\xA3")
+
 var tifII = []byte("\x49\x49\x2A\x00")
 var tifMM = []byte("\x4D\x4D\x00\x2A")
 
@@ -326,6 +342,12 @@ func (b *Blob) doInit() {
 			b.blobType = BlobTypePDF
 		} else if bytes.Equal(b.sniffBuf[:2], bmpHeader) {
 			b.blobType = BlobTypeBMP
+		} else if bytes.Equal(b.sniffBuf[:2], jxlCodestream) || bytes.Equal(b.sniffBuf[:12], jxlContainer) {
+			b.blobType = BlobTypeJXL
+		} else if bytes.Equal(b.sniffBuf[4:8], ftyp) && isMP4Brand(b.sniffBuf[8:12]) {
+			b.blobType = BlobTypeMP4
+		} else if bytes.Equal(b.sniffBuf[:4], webmHeader) {
+			b.blobType = BlobTypeWEBM
 		}
 	}
 	if b.contentType == "" {
@@ -354,6 +376,12 @@ func (b *Blob) doInit() {
 			b.contentType = "image/bmp"
 		case BlobTypeSVG:
 			b.contentType = "image/svg+xml"
+		case BlobTypeJXL:
+			b.contentType = "image/jxl"
+		case BlobTypeMP4:
+			b.contentType = "video/mp4"
+		case BlobTypeWEBM:
+			b.contentType = "video/webm"
 		default:
 			b.contentType = http.DetectContentType(b.sniffBuf)
 		}
@@ -392,6 +420,12 @@ func (b *Blob) SupportsAnimation() bool {
 	return b.blobType == BlobTypeGIF || b.blobType == BlobTypeWEBP
 }
 
+// IsVideo check if blob is video
+func (b *Blob) IsVideo() bool {
+	b.init()
+	return b.blobType == BlobTypeMP4 || b.blobType == BlobTypeWEBM
+}
+
 // BlobType returns BlobType
 func (b *Blob) BlobType() BlobType {
 	b.init()
@@ -519,6 +553,15 @@ func checkBlob(blob *Blob, err error) (*Blob, error) {
 	return blob, err
 }
 
+func isMP4Brand(brand []byte) bool {
+	for _, b := range mp4Brands {
+		if bytes.Equal(brand, b) {
+			return true
+		}
+	}
+	return false
+}
+
 func getExtension(typ BlobType) (ext string) {
 	switch typ {
 	case BlobTypeJPEG:
@@ -545,6 +588,12 @@ func getExtension(typ BlobType) (ext string) {
 		ext = ".json"
 	case BlobTypeSVG:
 		ext = ".svg"
+	case BlobTypeJXL:
+		ext = ".jxl"
+	case BlobTypeMP4:
+		ext = ".mp4"
+	case BlobTypeWEBM:
+		ext = ".webm"
 	}
 	return
 }
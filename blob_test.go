@@ -222,6 +222,45 @@ func TestBlobTypes(t *testing.T) {
 	}
 }
 
+func TestBlobTypeJXL(t *testing.T) {
+	for name, header := range map[string][]byte{
+		"codestream": {0xFF, 0x0A},
+		"container":  {0x00, 0x00, 0x00, 0x0C, 0x4A, 0x58, 0x4C, 0x20, 0x0D, 0x0A, 0x87, 0x0A},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := NewBlobFromBytes(append(header, make([]byte, 64)...))
+			assert.Equal(t, BlobTypeJXL, b.BlobType())
+			assert.Equal(t, "image/jxl", b.ContentType())
+			assert.Equal(t, ".jxl", getExtension(b.BlobType()))
+			assert.False(t, b.SupportsAnimation())
+		})
+	}
+}
+
+func TestBlobTypeVideo(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		header      []byte
+		blobType    BlobType
+		contentType string
+		ext         string
+	}{
+		{"mp4", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), BlobTypeMP4, "video/mp4", ".mp4"},
+		{"mp4 mp42", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00"), BlobTypeMP4, "video/mp4", ".mp4"},
+		{"webm", []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01"), BlobTypeWEBM, "video/webm", ".webm"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBlobFromBytes(append(tt.header, make([]byte, 64)...))
+			assert.Equal(t, tt.blobType, b.BlobType())
+			assert.Equal(t, tt.contentType, b.ContentType())
+			assert.Equal(t, tt.ext, getExtension(b.BlobType()))
+			assert.True(t, b.IsVideo())
+			assert.False(t, b.SupportsAnimation())
+		})
+	}
+	assert.False(t, NewBlobFromBytes([]byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00"+string(make([]byte, 64)))).IsVideo())
+}
+
 func TestNewEmptyBlob(t *testing.T) {
 	b := NewBlobFromBytes([]byte{})
 	assert.Empty(t, b.Sniff())
@@ -1,20 +1,46 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/cshum/imagor/config"
 	"github.com/cshum/imagor/config/awsconfig"
+	"github.com/cshum/imagor/config/azureconfig"
+	"github.com/cshum/imagor/config/ffmpegconfig"
 	"github.com/cshum/imagor/config/gcloudconfig"
+	"github.com/cshum/imagor/config/otelconfig"
+	"github.com/cshum/imagor/config/redisconfig"
 	"github.com/cshum/imagor/config/vipsconfig"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	var server = config.CreateServer(
-		os.Args[1:],
+	var funcs = []config.Option{
 		vipsconfig.WithVips,
+		ffmpegconfig.WithFFmpeg,
 		awsconfig.WithAWS,
 		gcloudconfig.WithGCloud,
-	)
+		azureconfig.WithAzure,
+		redisconfig.WithRedis,
+		otelconfig.WithOTel,
+	}
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		ctx, cancel := signal.NotifyContext(
+			context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		report, err := config.RunBatch(ctx, os.Args[2:], funcs...)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if report.Failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	var server = config.CreateServer(os.Args[1:], funcs...)
 	if server != nil {
 		server.Run()
 	}
@@ -0,0 +1,112 @@
+package azureconfig
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/storage/azurestorage"
+	"go.uber.org/zap"
+)
+
+// WithAzure with Azure Blob Storage Loader, Storage and Result Storage config option
+func WithAzure(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+	var (
+		azureConnectionString = fs.String("azure-connection-string", "",
+			"Azure Storage connection string. Overrides account name and key if present")
+		azureAccountName = fs.String("azure-account-name", "",
+			"Azure Storage account name. Required if using Azure Loader or Storage without connection string")
+		azureAccountKey = fs.String("azure-account-key", "",
+			"Azure Storage account key. Anonymous access if not present")
+		azureEndpoint = fs.String("azure-endpoint", "",
+			"Optional Azure Blob service endpoint to override default https://{account-name}.blob.core.windows.net/")
+		azureSafeChars = fs.String("azure-safe-chars", "",
+			"Azure safe characters to be excluded from image key escape")
+
+		azureLoaderContainer = fs.String("azure-loader-container", "",
+			"Azure container for Azure Loader. Enable Azure Loader only if this value present")
+		azureLoaderBaseDir = fs.String("azure-loader-base-dir", "",
+			"Base directory for Azure Loader")
+		azureLoaderPathPrefix = fs.String("azure-loader-path-prefix", "",
+			"Base path prefix for Azure Loader")
+
+		azureStorageContainer = fs.String("azure-storage-container", "",
+			"Azure container for Azure Storage. Enable Azure Storage only if this value present")
+		azureStorageBaseDir = fs.String("azure-storage-base-dir", "",
+			"Base directory for Azure Storage")
+		azureStoragePathPrefix = fs.String("azure-storage-path-prefix", "",
+			"Base path prefix for Azure Storage")
+		azureStorageExpiration = fs.Duration("azure-storage-expiration", 0,
+			"Azure Storage expiration duration e.g. 24h. Default no expiration")
+
+		azureResultStorageContainer = fs.String("azure-result-storage-container", "",
+			"Azure container for Azure Result Storage. Enable Azure Result Storage only if this value present")
+		azureResultStorageBaseDir = fs.String("azure-result-storage-base-dir", "",
+			"Base directory for Azure Result Storage")
+		azureResultStoragePathPrefix = fs.String("azure-result-storage-path-prefix", "",
+			"Base path prefix for Azure Result Storage")
+		azureResultStorageExpiration = fs.Duration("azure-result-storage-expiration", 0,
+			"Azure Result Storage expiration duration e.g. 24h. Default no expiration")
+
+		_, _ = cb()
+	)
+	return func(app *imagor.Imagor) {
+		if *azureStorageContainer == "" && *azureLoaderContainer == "" && *azureResultStorageContainer == "" {
+			return
+		}
+		// activate the client, will panic if credentials are invalid
+		client, err := newClient(*azureConnectionString, *azureAccountName, *azureAccountKey, *azureEndpoint)
+		if err != nil {
+			panic(err)
+		}
+		if *azureStorageContainer != "" {
+			// activate Azure Storage only if container config presents
+			app.Storages = append(app.Storages,
+				azurestorage.New(client, *azureStorageContainer,
+					azurestorage.WithPathPrefix(*azureStoragePathPrefix),
+					azurestorage.WithBaseDir(*azureStorageBaseDir),
+					azurestorage.WithSafeChars(*azureSafeChars),
+					azurestorage.WithExpiration(*azureStorageExpiration),
+				),
+			)
+		}
+		if *azureLoaderContainer != "" {
+			// activate Azure Loader only if container config presents
+			app.Loaders = append(app.Loaders,
+				azurestorage.New(client, *azureLoaderContainer,
+					azurestorage.WithPathPrefix(*azureLoaderPathPrefix),
+					azurestorage.WithBaseDir(*azureLoaderBaseDir),
+					azurestorage.WithSafeChars(*azureSafeChars),
+				),
+			)
+		}
+		if *azureResultStorageContainer != "" {
+			// activate Azure Result Storage only if container config presents
+			app.ResultStorages = append(app.ResultStorages,
+				azurestorage.New(client, *azureResultStorageContainer,
+					azurestorage.WithPathPrefix(*azureResultStoragePathPrefix),
+					azurestorage.WithBaseDir(*azureResultStorageBaseDir),
+					azurestorage.WithSafeChars(*azureSafeChars),
+					azurestorage.WithExpiration(*azureResultStorageExpiration),
+				),
+			)
+		}
+	}
+}
+
+func newClient(connectionString, accountName, accountKey, endpoint string) (*azblob.Client, error) {
+	if connectionString != "" {
+		return azblob.NewClientFromConnectionString(connectionString, nil)
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", accountName)
+	}
+	if accountName != "" && accountKey != "" {
+		cred, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+		if err != nil {
+			return nil, err
+		}
+		return azblob.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+	}
+	return azblob.NewClientWithNoCredential(endpoint, nil)
+}
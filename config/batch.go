@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/batch"
+	"github.com/peterbourgon/ff/v3"
+	"go.uber.org/zap"
+)
+
+// RunBatch run batch processing from config flags, with the same imagor config as server.
+// Reads tasks from input and writes JSON report of failures
+func RunBatch(ctx context.Context, args []string, funcs ...Option) (report *batch.Report, err error) {
+	var (
+		fs     = flag.NewFlagSet("imagor batch", flag.ExitOnError)
+		logger *zap.Logger
+		app    *imagor.Imagor
+
+		debug = fs.Bool("debug", false, "Debug mode")
+
+		_ = fs.String("config", ".env", "Retrieve configuration from the given file")
+
+		batchInput = fs.String("batch-input", "-",
+			"Batch input file of imagor paths or JSON lines manifest of source, params and destination. Read from stdin if -")
+		batchReport = fs.String("batch-report", "-",
+			"Batch JSON report output file. Write to stdout if -")
+		batchConcurrency = fs.Int("batch-concurrency", 0,
+			"Batch maximum number of tasks run simultaneously. Default number of CPUs")
+		batchOutputDir = fs.String("batch-output-dir", "",
+			"Batch output directory to write processed images")
+		batchOutputStorage = fs.Bool("batch-output-storage", false,
+			"Batch write processed images to the configured imagor Storages")
+	)
+
+	app = NewImagor(fs, func() (*zap.Logger, bool) {
+		if err = ff.Parse(fs, args,
+			ff.WithEnvVars(),
+			ff.WithConfigFileFlag("config"),
+			ff.WithIgnoreUndefined(true),
+			ff.WithAllowMissingConfigFile(true),
+			ff.WithConfigFileParser(ff.EnvParser),
+		); err != nil {
+			panic(err)
+		}
+		if *debug {
+			logger = zap.Must(zap.NewDevelopment())
+		} else {
+			logger = zap.Must(zap.NewProduction())
+		}
+		return logger, *debug
+	}, funcs...)
+
+	var input io.Reader = os.Stdin
+	if *batchInput != "-" && *batchInput != "" {
+		file, err := os.Open(*batchInput)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		input = file
+	}
+	tasks, err := batch.ReadTasks(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var options = []batch.Option{
+		batch.WithConcurrency(*batchConcurrency),
+		batch.WithOutputDir(*batchOutputDir),
+		batch.WithLogger(logger),
+	}
+	if *batchOutputStorage {
+		options = append(options, batch.WithOutputStorages(app.Storages...))
+	}
+
+	if err = app.Startup(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if e := app.Shutdown(context.Background()); e != nil {
+			logger.Error("app-shutdown", zap.Error(e))
+		}
+	}()
+	report = batch.New(app, options...).Run(ctx, tasks)
+	logger.Info("batch",
+		zap.Int("total", report.Total),
+		zap.Int("succeeded", report.Succeeded),
+		zap.Int("failed", report.Failed),
+		zap.String("duration", report.Duration))
+
+	var output io.Writer = os.Stdout
+	if *batchReport != "-" && *batchReport != "" {
+		file, err := os.Create(*batchReport)
+		if err != nil {
+			return report, err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		output = file
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(report)
+	return
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cshum/imagor/batch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBatch(t *testing.T) {
+	srcDir := t.TempDir()
+	outDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "foo.jpg"), []byte("foo"), 0644))
+	input := filepath.Join(t.TempDir(), "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte(
+		"/unsafe/fit-in/200x200/foo.jpg\n"+
+			`{"source":"foo.jpg","params":"100x100","destination":"thumb/foo.jpg"}`+"\n"+
+			"missing.jpg\n",
+	), 0644))
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+
+	report, err := RunBatch(context.Background(), []string{
+		"-http-loader-disable",
+		"-file-loader-base-dir", srcDir,
+		"-batch-input", input,
+		"-batch-report", reportFile,
+		"-batch-output-dir", outDir,
+		"-batch-concurrency", "2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, 1, report.Failed)
+
+	buf, err := os.ReadFile(filepath.Join(outDir, "thumb/foo.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+	buf, err = os.ReadFile(filepath.Join(outDir, "fit-in/200x200/foo.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+
+	buf, err = os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var written batch.Report
+	require.NoError(t, json.Unmarshal(buf, &written))
+	assert.Equal(t, 1, written.Failed)
+	assert.Equal(t, "missing.jpg", written.Failures[0].Path)
+	assert.Equal(t, 404, written.Failures[0].Status)
+
+	_, err = RunBatch(context.Background(), []string{
+		"-batch-input", filepath.Join(srcDir, "not-exists.txt"),
+	})
+	assert.Error(t, err)
+}
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"crypto/ed25519"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
 	"strings"
 	"time"
@@ -17,26 +23,48 @@ import (
 	"github.com/cshum/imagor/server"
 	"github.com/peterbourgon/ff/v3"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
 var baseConfig = []Option{
 	withFileSystem,
 	withHTTPLoader,
+	withMemory,
 }
 
 // NewImagor create imagor from config flags
 func NewImagor(
 	fs *flag.FlagSet, cb func() (*zap.Logger, bool), funcs ...Option,
+) *imagor.Imagor {
+	return newImagor(fs, cb, baseConfig, funcs...)
+}
+
+func newImagor(
+	fs *flag.FlagSet, cb func() (*zap.Logger, bool), base []Option, funcs ...Option,
 ) *imagor.Imagor {
 	var (
 		imagorSecret = fs.String("imagor-secret", "",
 			"Secret key for signing imagor URL")
+		imagorSecrets = fs.String("imagor-secrets", "",
+			"Comma separated legacy secret keys accepted for imagor URL signature verification in addition to imagor-secret, for key rotation")
+		imagorSecretRetireAt = fs.String("imagor-secret-retire-at", "",
+			"Comma separated RFC3339 times that the corresponding imagor-secrets are no longer accepted, of the same count as imagor-secrets. Empty entry for no expiry")
+		imagorPublicKeys = fs.String("imagor-public-keys", "",
+			"Comma separated Ed25519 public keys for verifying imagor URL signature with key ID, in form of <keyID>:<base64 key> e.g. partner1:MCowBQYDK2VwAyEA...")
 		imagorUnsafe = fs.Bool("imagor-unsafe", false,
 			"Unsafe imagor that does not require URL signature. Prone to URL tampering")
 		imagorAutoWebP = fs.Bool("imagor-auto-webp", false,
 			"Output WebP format automatically if browser supports")
 		imagorAutoAVIF = fs.Bool("imagor-auto-avif", false,
 			"Output AVIF format automatically if browser supports (experimental)")
+		imagorAutoJXL = fs.Bool("imagor-auto-jxl", false,
+			"Output JPEG XL format automatically if browser supports (experimental)")
+		imagorClientHints = fs.Bool("imagor-client-hints", false,
+			"Scale image dimensions automatically based on browser Sec-CH-DPR, Sec-CH-Width and Sec-CH-Viewport-Width client hints")
+		imagorClientHintsMaxWidth = fs.Int("imagor-client-hints-max-width", imagor.DefaultClientHintsMaxSize,
+			"Maximum image width scaled by client hints")
+		imagorClientHintsMaxHeight = fs.Int("imagor-client-hints-max-height", imagor.DefaultClientHintsMaxSize,
+			"Maximum image height scaled by client hints")
 		imagorRequestTimeout = fs.Duration("imagor-request-timeout",
 			time.Second*30, "Timeout for performing imagor request")
 		imagorLoadTimeout = fs.Duration("imagor-load-timeout",
@@ -63,24 +91,72 @@ func NewImagor(
 			"Check modified time of result image against the source image. This eliminates stale result but require more lookups")
 		imagorDisableErrorBody       = fs.Bool("imagor-disable-error-body", false, "imagor disable response body on error")
 		imagorDisableParamsEndpoint  = fs.Bool("imagor-disable-params-endpoint", false, "imagor disable /params endpoint")
+		imagorUploadEnabled          = fs.Bool("imagor-upload-enabled", false, "imagor enable POST and PUT upload of source image as request body")
+		imagorUploadMaxSize          = fs.Int64("imagor-upload-max-size", imagor.DefaultUploadMaxSize, "imagor maximum upload request body size in bytes")
+		imagorAdminSecret            = fs.String("imagor-admin-secret", "", "imagor admin secret for authenticating admin endpoints e.g. DELETE /purge/<image>. Admin endpoints disabled if not set")
+		imagorPresets                = fs.String("imagor-presets", "", "imagor named presets as JSON object of preset name to params e.g. {\"thumb\":\"fit-in/150x150\"}, referenced in URL by preset:<name> or p/<name>, and warmed up by POST /warm/<image>")
+		imagorPresetsFile            = fs.String("imagor-presets-file", "", "imagor named presets YAML or JSON file of preset name to params. Presets of imagor-presets take precedence")
 		imagorSignerType             = fs.String("imagor-signer-type", "sha1", "imagor URL signature hasher type: sha1, sha256, sha512")
 		imagorSignerTruncate         = fs.Int("imagor-signer-truncate", 0, "imagor URL signature truncate at length")
 		imagorStoragePathStyle       = fs.String("imagor-storage-path-style", "original", "imagor storage path style: original, digest")
 		imagorResultStoragePathStyle = fs.String("imagor-result-storage-path-style", "original", "imagor result storage path style: original, digest, suffix")
 
-		options, logger, isDebug = applyOptions(fs, cb, append(funcs, baseConfig...)...)
+		options, logger, isDebug = applyOptions(fs, cb, append(funcs[:len(funcs):len(funcs)], base...)...)
 
 		alg          = sha1.New
 		hasher       imagorpath.StorageHasher
 		resultHasher imagorpath.ResultStorageHasher
+		presets      map[string]string
+		filePresets  map[string]string
 	)
 
+	if *imagorPresetsFile != "" {
+		buf, err := os.ReadFile(*imagorPresetsFile)
+		if err != nil {
+			panic(err)
+		}
+		// YAML is a superset of JSON
+		if err := yaml.Unmarshal(buf, &filePresets); err != nil {
+			panic(err)
+		}
+	}
+	if *imagorPresets != "" {
+		if err := json.Unmarshal([]byte(*imagorPresets), &presets); err != nil {
+			panic(err)
+		}
+	}
+
 	if strings.ToLower(*imagorSignerType) == "sha256" {
 		alg = sha256.New
 	} else if strings.ToLower(*imagorSignerType) == "sha512" {
 		alg = sha512.New
 	}
 
+	var signer imagorpath.Signer = imagorpath.NewHMACSigner(
+		alg, *imagorSignerTruncate, *imagorSecret,
+	)
+	if *imagorSecrets != "" || *imagorSecretRetireAt != "" {
+		if *imagorSecret == "" {
+			panic(errors.New("imagor-secrets: imagor-secret must be set for key rotation"))
+		}
+		legacy, err := parseLegacySecrets(*imagorSecrets, *imagorSecretRetireAt, func(secret string) imagorpath.Signer {
+			return imagorpath.NewHMACSigner(alg, *imagorSignerTruncate, secret)
+		})
+		if err != nil {
+			panic(err)
+		}
+		signer = imagorpath.NewMultiSigner(signer, legacy...)
+	}
+
+	var keyVerifier imagorpath.KeyVerifier
+	if *imagorPublicKeys != "" {
+		keys, err := parsePublicKeys(*imagorPublicKeys)
+		if err != nil {
+			panic(err)
+		}
+		keyVerifier = imagorpath.NewEd25519Verifier(keys)
+	}
+
 	if strings.ToLower(*imagorStoragePathStyle) == "digest" {
 		hasher = imagorpath.DigestStorageHasher
 	}
@@ -95,9 +171,8 @@ func NewImagor(
 
 	return imagor.New(append(
 		options,
-		imagor.WithSigner(imagorpath.NewHMACSigner(
-			alg, *imagorSignerTruncate, *imagorSecret,
-		)),
+		imagor.WithSigner(signer),
+		imagor.WithKeyVerifier(keyVerifier),
 		imagor.WithBasePathRedirect(*imagorBasePathRedirect),
 		imagor.WithBaseParams(*imagorBaseParams),
 		imagor.WithRequestTimeout(*imagorRequestTimeout),
@@ -111,9 +186,18 @@ func NewImagor(
 		imagor.WithCacheHeaderNoCache(*imagorCacheHeaderNoCache),
 		imagor.WithAutoWebP(*imagorAutoWebP),
 		imagor.WithAutoAVIF(*imagorAutoAVIF),
+		imagor.WithAutoJXL(*imagorAutoJXL),
+		imagor.WithClientHints(*imagorClientHints),
+		imagor.WithClientHintsMaxWidth(*imagorClientHintsMaxWidth),
+		imagor.WithClientHintsMaxHeight(*imagorClientHintsMaxHeight),
 		imagor.WithModifiedTimeCheck(*imagorModifiedTimeCheck),
 		imagor.WithDisableErrorBody(*imagorDisableErrorBody),
 		imagor.WithDisableParamsEndpoint(*imagorDisableParamsEndpoint),
+		imagor.WithUploadEnabled(*imagorUploadEnabled),
+		imagor.WithUploadMaxSize(*imagorUploadMaxSize),
+		imagor.WithAdminSecret(*imagorAdminSecret),
+		imagor.WithPresets(filePresets),
+		imagor.WithPresets(presets),
 		imagor.WithStoragePathStyle(hasher),
 		imagor.WithResultStoragePathStyle(resultHasher),
 		imagor.WithUnsafe(*imagorUnsafe),
@@ -130,6 +214,8 @@ func CreateServer(args []string, funcs ...Option) (srv *server.Server) {
 		err    error
 		app    *imagor.Imagor
 
+		processors = newProcessorOptions()
+
 		debug        = fs.Bool("debug", false, "Debug mode")
 		version      = fs.Bool("version", false, "imagor version")
 		port         = fs.Int("port", 8000, "Server port")
@@ -150,6 +236,26 @@ func CreateServer(args []string, funcs ...Option) (srv *server.Server) {
 			"Enable strip query string redirection")
 		serverAccessLog = fs.Bool("server-access-log", false,
 			"Enable server access log")
+		serverTLSCert = fs.String("server-tls-cert", "",
+			"Server TLS certificate file. Enables HTTPS and HTTP/2 with server-tls-key")
+		serverTLSKey = fs.String("server-tls-key", "",
+			"Server TLS private key file")
+		serverTLSClientCA = fs.String("server-tls-client-ca", "",
+			"Server TLS client CA bundle file. Enables mutual TLS that requires verified client certificate")
+		serverTLSReloadInterval = fs.Duration("server-tls-reload-interval", time.Minute,
+			"Server TLS certificate file change polling interval for reload. Certificate also reloads on SIGHUP")
+
+		serverTenantsFile = fs.String("server-tenants-file", "",
+			"Server multi-tenant YAML or JSON file of tenant name to hosts, path_prefix and config of flag overrides. Tenants share the processors, process concurrency and queue of imagor, without memory result storage")
+
+		serverRateLimit = fs.Float64("server-rate-limit", 0,
+			"Server rate limit of requests per second refilled to the token bucket of each client. Set 0 for no limit")
+		serverRateLimitBurst = fs.Int("server-rate-limit-burst", 0,
+			"Server rate limit burst size of the token bucket of each client. Default the rate limit rounded up")
+		serverRateLimitKey = fs.String("server-rate-limit-key", "ip",
+			"Server rate limit client key: ip, remote, signature. ip trusts X-Forwarded-For and X-Real-Ip headers, remote keys by connection remote address, signature keys by the key ID of Ed25519 URL signature, HMAC signed and unsafe URLs fallback to ip")
+		serverRateLimitExemptResultStorage = fs.Bool("server-rate-limit-exempt-result-storage", false,
+			"Server rate limit only applies on result storage miss before image processing, exempting result storage hits")
 
 		prometheusBind = fs.String("prometheus-bind", "", "Specify address and port to enable Prometheus metrics, e.g. :5000, prom:7000")
 		prometheusPath = fs.String("prometheus-path", "/", "Prometheus metrics path")
@@ -171,7 +277,7 @@ func CreateServer(args []string, funcs ...Option) (srv *server.Server) {
 			logger = zap.Must(zap.NewProduction())
 		}
 		return logger, *debug
-	}, funcs...)
+	}, processors.track(funcs)...)
 
 	if *version {
 		fmt.Println(imagor.Version)
@@ -190,6 +296,33 @@ func CreateServer(args []string, funcs ...Option) (srv *server.Server) {
 			prometheusmetrics.WithPath(*prometheusPath),
 			prometheusmetrics.WithLogger(logger),
 		)
+		// observe imagor internals with prometheus metrics
+		app.Metrics = pm
+	}
+
+	var tenants []server.Tenant
+	if *serverTenantsFile != "" {
+		if tenants, err = newTenants(*serverTenantsFile, fs, app, processors, logger, *debug, funcs...); err != nil {
+			panic(err)
+		}
+	}
+
+	if (*serverTLSCert == "") != (*serverTLSKey == "") {
+		panic(errors.New("server-tls-cert and server-tls-key must be set together"))
+	}
+	if *serverTLSClientCA != "" && *serverTLSCert == "" {
+		panic(errors.New("server-tls-client-ca requires server-tls-cert and server-tls-key"))
+	}
+
+	var rateLimitKey = server.RateLimitByIP
+	switch strings.ToLower(*serverRateLimitKey) {
+	case "signature":
+		rateLimitKey = server.RateLimitBySignature
+		if *serverRateLimit > 0 && app.KeyVerifier == nil {
+			logger.Warn("server-rate-limit-key signature requires imagor-public-keys, fallback to ip for all requests")
+		}
+	case "remote":
+		rateLimitKey = server.RateLimitByRemoteAddr
 	}
 
 	return server.New(app,
@@ -200,8 +333,78 @@ func CreateServer(args []string, funcs ...Option) (srv *server.Server) {
 		server.WithCORS(*serverCORS),
 		server.WithStripQueryString(*serverStripQueryString),
 		server.WithAccessLog(*serverAccessLog),
+		server.WithTLS(*serverTLSCert, *serverTLSKey),
+		server.WithTLSClientCA(*serverTLSClientCA),
+		server.WithTLSReloadInterval(*serverTLSReloadInterval),
 		server.WithLogger(logger),
 		server.WithDebug(*debug),
 		server.WithMetrics(pm),
+		server.WithTenants(tenants...),
+		server.WithRateLimiter(server.NewRateLimiter(
+			*serverRateLimit, *serverRateLimitBurst, rateLimitKey, *serverRateLimitExemptResultStorage,
+		)),
 	)
 }
+
+// parseLegacySecrets parses comma separated legacy secrets and the corresponding RFC3339 retire times,
+// which must be of the same count if retire times are set. Empty retire time for no expiry
+func parseLegacySecrets(
+	secrets, retireAt string, newSigner func(secret string) imagorpath.Signer,
+) ([]imagorpath.LegacySigner, error) {
+	var secretList = strings.Split(secrets, ",")
+	var retireList []string
+	if retireAt != "" {
+		retireList = strings.Split(retireAt, ",")
+		if len(retireList) != len(secretList) {
+			return nil, fmt.Errorf("imagor-secret-retire-at: %d entries for %d imagor-secrets", len(retireList), len(secretList))
+		}
+	}
+	var legacy []imagorpath.LegacySigner
+	for i, secret := range secretList {
+		if secret = strings.TrimSpace(secret); secret == "" {
+			return nil, fmt.Errorf("imagor-secrets: empty secret at position %d", i+1)
+		}
+		var l = imagorpath.LegacySigner{Signer: newSigner(secret)}
+		if i < len(retireList) && strings.TrimSpace(retireList[i]) != "" {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(retireList[i]))
+			if err != nil {
+				return nil, fmt.Errorf("imagor-secret-retire-at: %w", err)
+			}
+			l.RetireAt = t
+		}
+		legacy = append(legacy, l)
+	}
+	return legacy, nil
+}
+
+// parsePublicKeys parses comma separated <keyID>:<base64 key> of Ed25519 public keys,
+// in raw 32 bytes or PKIX DER form
+func parsePublicKeys(s string) (map[string]ed25519.PublicKey, error) {
+	var keys = map[string]ed25519.PublicKey{}
+	for _, entry := range strings.Split(s, ",") {
+		keyID, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("imagor-public-keys: invalid entry %q", entry)
+		}
+		buf, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			if buf, err = base64.URLEncoding.DecodeString(encoded); err != nil {
+				return nil, fmt.Errorf("imagor-public-keys: %s: %w", keyID, err)
+			}
+		}
+		if len(buf) == ed25519.PublicKeySize {
+			keys[keyID] = buf
+			continue
+		}
+		pub, err := x509.ParsePKIXPublicKey(buf)
+		if err != nil {
+			return nil, fmt.Errorf("imagor-public-keys: %s: %w", keyID, err)
+		}
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("imagor-public-keys: %s: not Ed25519 public key", keyID)
+		}
+		keys[keyID] = key
+	}
+	return keys, nil
+}
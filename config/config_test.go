@@ -1,13 +1,24 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"flag"
 	"github.com/cshum/imagor"
 	"github.com/cshum/imagor/imagorpath"
 	"github.com/cshum/imagor/loader/httploader"
 	"github.com/cshum/imagor/metrics/prometheusmetrics"
 	"github.com/cshum/imagor/storage/filestorage"
+	"github.com/cshum/imagor/storage/memorystorage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -29,8 +40,15 @@ func TestDefault(t *testing.T) {
 	assert.False(t, app.ModifiedTimeCheck)
 	assert.False(t, app.AutoWebP)
 	assert.False(t, app.AutoAVIF)
+	assert.False(t, app.AutoJXL)
+	assert.False(t, app.ClientHints)
 	assert.False(t, app.DisableErrorBody)
 	assert.False(t, app.DisableParamsEndpoint)
+	assert.False(t, app.UploadEnabled)
+	assert.Equal(t, int64(32<<20), app.UploadMaxSize)
+	assert.Empty(t, app.AdminSecret)
+	assert.Empty(t, app.Presets)
+	assert.Nil(t, app.Metrics)
 	assert.Equal(t, time.Hour*24*7, app.CacheHeaderTTL)
 	assert.Equal(t, time.Hour*24, app.CacheHeaderSWR)
 	assert.Empty(t, app.ResultStorages)
@@ -48,8 +66,16 @@ func TestBasic(t *testing.T) {
 		"-imagor-unsafe",
 		"-imagor-auto-webp",
 		"-imagor-auto-avif",
+		"-imagor-auto-jxl",
+		"-imagor-client-hints",
+		"-imagor-client-hints-max-width", "2000",
+		"-imagor-client-hints-max-height", "1500",
 		"-imagor-disable-error-body",
 		"-imagor-disable-params-endpoint",
+		"-imagor-upload-enabled",
+		"-imagor-upload-max-size", "1024",
+		"-imagor-admin-secret", "s3cret",
+		"-imagor-presets", `{"thumb":"fit-in/150x150","card":"300x200/filters:format(webp)"}`,
 		"-imagor-request-timeout", "16s",
 		"-imagor-load-timeout", "7s",
 		"-imagor-process-timeout", "19s",
@@ -69,8 +95,19 @@ func TestBasic(t *testing.T) {
 	assert.True(t, app.Debug)
 	assert.True(t, app.Unsafe)
 	assert.True(t, app.AutoWebP)
+	assert.True(t, app.AutoJXL)
+	assert.True(t, app.ClientHints)
+	assert.Equal(t, 2000, app.ClientHintsMaxWidth)
+	assert.Equal(t, 1500, app.ClientHintsMaxHeight)
 	assert.True(t, app.DisableErrorBody)
 	assert.True(t, app.DisableParamsEndpoint)
+	assert.True(t, app.UploadEnabled)
+	assert.Equal(t, int64(1024), app.UploadMaxSize)
+	assert.Equal(t, "s3cret", app.AdminSecret)
+	assert.Equal(t, imagorpath.Presets{
+		"thumb": "fit-in/150x150",
+		"card":  "300x200/filters:format(webp)",
+	}, app.Presets)
 	assert.Equal(t, "RrTsWGEXFU2s1J1mTl1j_ciO-1E=", app.Signer.Sign("bar"))
 	assert.Equal(t, time.Second*16, app.RequestTimeout)
 	assert.Equal(t, time.Second*7, app.LoadTimeout)
@@ -100,6 +137,65 @@ func TestBind(t *testing.T) {
 	assert.Equal(t, ":4567", srv.Addr)
 }
 
+func TestSignerSecrets(t *testing.T) {
+	srv := CreateServer([]string{
+		"-imagor-secret", "foo",
+		"-imagor-secrets", "bar, baz",
+		"-imagor-secret-retire-at", "2020-01-01T00:00:00Z,",
+	})
+	app := srv.App.(*imagor.Imagor)
+	signer := app.Signer.(*imagorpath.MultiSigner)
+	assert.Equal(t, imagorpath.NewDefaultSigner("foo").Sign("bar"), signer.Sign("bar"))
+	assert.Len(t, signer.Legacy, 2)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), signer.Legacy[0].RetireAt)
+	assert.True(t, signer.Legacy[1].RetireAt.IsZero())
+	assert.Equal(t, imagorpath.NewDefaultSigner("baz").Sign("bar"), signer.Legacy[1].Signer.Sign("bar"))
+	_, ok := signer.Verify("bar", imagorpath.NewDefaultSigner("bar").Sign("bar"))
+	assert.False(t, ok, "retired")
+	key, ok := signer.Verify("bar", imagorpath.NewDefaultSigner("baz").Sign("bar"))
+	assert.True(t, ok)
+	assert.Equal(t, 2, key)
+
+	for _, args := range [][]string{
+		{"-imagor-secret", "foo", "-imagor-secrets", "bar,baz", "-imagor-secret-retire-at", "2020-01-01T00:00:00Z"},
+		{"-imagor-secret", "foo", "-imagor-secrets", "bar", "-imagor-secret-retire-at", "2020-01-01T00:00:00Z,2021-01-01T00:00:00Z"},
+		{"-imagor-secret", "foo", "-imagor-secret-retire-at", "2020-01-01T00:00:00Z"},
+		{"-imagor-secret", "foo", "-imagor-secrets", "bar,,baz"},
+		{"-imagor-secret", "foo", "-imagor-secrets", "bar", "-imagor-secret-retire-at", "yesterday"},
+		{"-imagor-secrets", "bar"},
+	} {
+		assert.Panics(t, func() {
+			CreateServer(args)
+		}, strings.Join(args, " "))
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub := key.Public().(ed25519.PublicKey)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	srv := CreateServer([]string{
+		"-imagor-public-keys", "partner1:" + base64.StdEncoding.EncodeToString(pub) +
+			",partner2:" + base64.StdEncoding.EncodeToString(der),
+	})
+	app := srv.App.(*imagor.Imagor)
+	sig := imagorpath.NewEd25519Signer("", key).Sign("bar")[1:]
+	assert.True(t, app.KeyVerifier.VerifyKey("partner1", "bar", sig))
+	assert.True(t, app.KeyVerifier.VerifyKey("partner2", "bar", sig))
+	assert.False(t, app.KeyVerifier.VerifyKey("partner3", "bar", sig))
+
+	assert.Nil(t, CreateServer(nil).App.(*imagor.Imagor).KeyVerifier)
+
+	_, err = parsePublicKeys("partner1")
+	assert.Error(t, err)
+	_, err = parsePublicKeys("partner1:!!!")
+	assert.Error(t, err)
+	_, err = parsePublicKeys("partner1:" + base64.StdEncoding.EncodeToString([]byte("foo")))
+	assert.Error(t, err)
+}
+
 func TestSignerAlgorithm(t *testing.T) {
 	srv := CreateServer([]string{
 		"-imagor-signer-type", "sha256",
@@ -164,6 +260,19 @@ func TestFileStorage(t *testing.T) {
 	assert.Equal(t, "!", resultStorage.SafeChars)
 }
 
+func TestMemoryResultStorage(t *testing.T) {
+	srv := CreateServer([]string{
+		"-file-result-storage-base-dir", "./bar",
+		"-memory-result-storage-size", "1024",
+	})
+	app := srv.App.(*imagor.Imagor)
+	assert.Equal(t, 2, len(app.ResultStorages))
+	memoryStorage := app.ResultStorages[0].(*memorystorage.MemoryStorage)
+	assert.Equal(t, int64(1024), memoryStorage.MaxSize)
+	resultStorage := app.ResultStorages[1].(*filestorage.FileStorage)
+	assert.Equal(t, "./bar", resultStorage.BaseDir)
+}
+
 func TestPathStyle(t *testing.T) {
 	srv := CreateServer([]string{
 		"-imagor-storage-path-style", "digest",
@@ -186,6 +295,192 @@ func TestPathStyle(t *testing.T) {
 	assert.Equal(t, "abc.30fdbe2aa5086e0f0c50_200x200", app.ResultStoragePathStyle.HashResult(imagorpath.Parse("200x200/abc")))
 }
 
+func TestPresetsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "presets.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(
+		"card: fit-in/300x200/filters:format(webp):quality(80)\n"+
+			"hero: 1200x0\n"), 0644))
+	srv := CreateServer([]string{
+		"-imagor-presets-file", file,
+		"-imagor-presets", `{"hero":"1600x0"}`,
+	})
+	app := srv.App.(*imagor.Imagor)
+	assert.Equal(t, imagorpath.Presets{
+		"card": "fit-in/300x200/filters:format(webp):quality(80)",
+		"hero": "1600x0",
+	}, app.Presets)
+
+	assert.Panics(t, func() {
+		CreateServer([]string{"-imagor-presets-file", filepath.Join(t.TempDir(), "missing.yml")})
+	})
+}
+
+type testProcessor struct {
+	imagor.Processor
+	Name string
+}
+
+// withTestProcessor test processor config option counting processors created
+func withTestProcessor(count *int) Option {
+	return func(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+		var (
+			enabled = fs.Bool("test-processor-enabled", true, "")
+			name    = fs.String("test-processor-name", "", "")
+
+			_, _ = cb()
+		)
+		return func(app *imagor.Imagor) {
+			if *enabled {
+				*count++
+				app.Processors = append(app.Processors, &testProcessor{Name: *name})
+			}
+		}
+	}
+}
+
+func TestTenantsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tenants.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+brand-a:
+  hosts: [a.example.com]
+  config:
+    imagor-secret: "1234"
+    imagor-base-params: filters:fill(white)
+    http-loader-allowed-sources: "*.a.example.com"
+    file-result-storage-base-dir: ./a
+    file-result-storage-path-prefix: brand-a
+brand-b:
+  path_prefix: /b
+  config:
+    imagor-unsafe: true
+`), 0644))
+	var count int
+	srv := CreateServer([]string{
+		"-imagor-secret", "5678",
+		"-imagor-signer-type", "sha256",
+		"-imagor-process-concurrency", "4",
+		"-http-loader-base-url", "https://www.example.com",
+		"-memory-result-storage-size", "1000",
+		"-test-processor-name", "shared",
+		"-server-tenants-file", file,
+	}, withTestProcessor(&count))
+	app := srv.App.(*imagor.Imagor)
+	assert.Len(t, srv.Tenants, 2)
+	assert.Equal(t, 1, count, "processors created once")
+	for _, tenant := range srv.Tenants {
+		tenantApp := tenant.Handler.(*imagor.Imagor)
+		assert.Equal(t, int64(4), tenantApp.ProcessConcurrency, "shared")
+		assert.Equal(t, app.Processors, tenantApp.Processors, "shared")
+		for _, storage := range tenantApp.ResultStorages {
+			assert.IsType(t, &filestorage.FileStorage{}, storage, "no memory result storage")
+		}
+	}
+
+	a := srv.Tenants[0]
+	assert.Equal(t, "brand-a", a.Name)
+	assert.Equal(t, []string{"a.example.com"}, a.Hosts)
+	tenantA := a.Handler.(*imagor.Imagor)
+	assert.NotSame(t, app, tenantA)
+	assert.Equal(t, app.Processors, tenantA.Processors)
+	assert.Equal(t, imagorpath.NewHMACSigner(sha256.New, 0, "1234").Sign("bar"), tenantA.Signer.Sign("bar"))
+	assert.Equal(t, imagorpath.NewHMACSigner(sha256.New, 0, "5678").Sign("bar"), app.Signer.Sign("bar"))
+	assert.Equal(t, "filters:fill(white)/", tenantA.BaseParams)
+	assert.Empty(t, app.BaseParams)
+	httpLoader := tenantA.Loaders[0].(*httploader.HTTPLoader)
+	assert.Equal(t, "https://www.example.com", httpLoader.BaseURL.String(), "inherited")
+	assert.Len(t, httpLoader.AllowedSources, 1)
+	assert.Empty(t, app.Loaders[0].(*httploader.HTTPLoader).AllowedSources)
+	assert.Equal(t, "/brand-a/", tenantA.ResultStorages[0].(*filestorage.FileStorage).PathPrefix)
+	assert.Len(t, app.ResultStorages, 1)
+	assert.IsType(t, &memorystorage.MemoryStorage{}, app.ResultStorages[0])
+
+	b := srv.Tenants[1]
+	assert.Equal(t, "brand-b", b.Name)
+	assert.Equal(t, "/b", b.PathPrefix)
+	assert.True(t, b.Handler.(*imagor.Imagor).Unsafe)
+	assert.False(t, app.Unsafe)
+
+	assert.NoError(t, os.WriteFile(file, []byte("brand-c:\n  hosts: [c.example.com]\n  config:\n    imagor-process-concurrency: 10\n"), 0644))
+	assert.Panics(t, func() {
+		CreateServer([]string{"-server-tenants-file", file})
+	}, "shared flags cannot be overridden")
+	for _, config := range []string{
+		"memory-result-storage-size: 1000",
+		"test-processor-name: tenant",
+	} {
+		assert.NoError(t, os.WriteFile(file, []byte("brand-c:\n  hosts: [c.example.com]\n  config:\n    "+config+"\n"), 0644))
+		assert.Panics(t, func() {
+			CreateServer([]string{"-server-tenants-file", file}, withTestProcessor(&count))
+		}, config)
+	}
+	assert.NoError(t, os.WriteFile(file, []byte("brand-c:\n  hosts: [c.example.com]\n  config:\n    test-processor-enabled: true\n"), 0644))
+	assert.Panics(t, func() {
+		CreateServer([]string{"-test-processor-enabled=false", "-server-tenants-file", file}, withTestProcessor(&count))
+	}, "processors cannot be added by tenant")
+	assert.NoError(t, os.WriteFile(file, []byte("brand-c:\n  hosts: [c.example.com]\n  config:\n    foo: bar\n"), 0644))
+	assert.Panics(t, func() {
+		CreateServer([]string{"-server-tenants-file", file})
+	})
+	assert.Panics(t, func() {
+		CreateServer([]string{"-server-tenants-file", filepath.Join(t.TempDir(), "missing.yml")})
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := CreateServer(nil)
+	assert.Nil(t, srv.RateLimiter)
+
+	srv = CreateServer([]string{
+		"-server-rate-limit", "2.5",
+	})
+	assert.Equal(t, 2.5, srv.RateLimiter.Rate)
+	assert.Equal(t, 3, srv.RateLimiter.Burst)
+	assert.False(t, srv.RateLimiter.ExemptResultStorage)
+
+	srv = CreateServer([]string{
+		"-server-rate-limit", "10",
+		"-server-rate-limit-burst", "50",
+		"-server-rate-limit-key", "signature",
+		"-server-rate-limit-exempt-result-storage",
+	})
+	assert.Equal(t, 10.0, srv.RateLimiter.Rate)
+	assert.Equal(t, 50, srv.RateLimiter.Burst)
+	assert.True(t, srv.RateLimiter.ExemptResultStorage)
+	r, _ := http.NewRequest(http.MethodGet, "/partner1:"+strings.Repeat("a", 88)+"/foo.jpg", nil)
+	assert.Equal(t, "key:partner1", srv.RateLimiter.Key(r))
+
+	srv = CreateServer([]string{
+		"-server-rate-limit", "10",
+		"-server-rate-limit-key", "remote",
+	})
+	r.RemoteAddr = "1.1.1.1:1234"
+	r.Header.Set("X-Forwarded-For", "8.8.8.8")
+	assert.Equal(t, "1.1.1.1", srv.RateLimiter.Key(r))
+}
+
+func TestServerTLS(t *testing.T) {
+	srv := CreateServer([]string{
+		"-server-tls-cert", "./cert.pem",
+		"-server-tls-key", "./key.pem",
+		"-server-tls-client-ca", "./ca.pem",
+		"-server-tls-reload-interval", "10s",
+	})
+	assert.Equal(t, "./cert.pem", srv.CertFile)
+	assert.Equal(t, "./key.pem", srv.KeyFile)
+	assert.Equal(t, "./ca.pem", srv.ClientCAFile)
+	assert.Equal(t, time.Second*10, srv.TLSReloadInterval)
+
+	for _, args := range [][]string{
+		{"-server-tls-cert", "./cert.pem"},
+		{"-server-tls-key", "./key.pem"},
+		{"-server-tls-client-ca", "./ca.pem"},
+	} {
+		assert.Panics(t, func() {
+			CreateServer(args)
+		}, strings.Join(args, " "))
+	}
+}
+
 func TestPrometheusBind(t *testing.T) {
 	srv := CreateServer([]string{
 		"-bind", ":2345",
@@ -196,4 +491,6 @@ func TestPrometheusBind(t *testing.T) {
 	pm := srv.Metrics.(*prometheusmetrics.PrometheusMetrics)
 	assert.Equal(t, pm.Path, "/myprom")
 	assert.Equal(t, pm.Addr, ":6789")
+	app := srv.App.(*imagor.Imagor)
+	assert.Equal(t, pm, app.Metrics)
 }
@@ -0,0 +1,35 @@
+package ffmpegconfig
+
+import (
+	"flag"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/ffmpeg"
+	"go.uber.org/zap"
+)
+
+// WithFFmpeg with ffmpeg video frame processor config option
+func WithFFmpeg(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+	var (
+		ffmpegEnabled = fs.Bool("ffmpeg-enabled", false,
+			"FFmpeg enable video frame extraction for MP4 and WebM sources, and MP4 output of animated images. Requires ffmpeg binary found on PATH")
+		ffmpegBinary = fs.String("ffmpeg-binary", "ffmpeg",
+			"FFmpeg binary name or path")
+
+		logger, isDebug = cb()
+	)
+	return func(app *imagor.Imagor) {
+		if !*ffmpegEnabled {
+			return
+		}
+		processor := ffmpeg.NewProcessor(
+			ffmpeg.WithBinary(*ffmpegBinary),
+			ffmpeg.WithLogger(logger),
+			ffmpeg.WithDebug(isDebug),
+		)
+		// ffmpeg processor goes first, forwarding extracted frame to the others,
+		// encoder goes last, encoding video forwarded from the others
+		app.Processors = append(append([]imagor.Processor{processor},
+			app.Processors...), processor.Encoder())
+	}
+}
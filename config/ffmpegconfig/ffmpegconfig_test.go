@@ -0,0 +1,25 @@
+package ffmpegconfig
+
+import (
+	"testing"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/config"
+	"github.com/cshum/imagor/ffmpeg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFFmpeg(t *testing.T) {
+	srv := config.CreateServer([]string{}, WithFFmpeg)
+	app := srv.App.(*imagor.Imagor)
+	assert.Empty(t, app.Processors)
+
+	srv = config.CreateServer([]string{
+		"-ffmpeg-enabled",
+		"-ffmpeg-binary", "/usr/local/bin/ffmpeg",
+	}, WithFFmpeg)
+	app = srv.App.(*imagor.Imagor)
+	processor := app.Processors[0].(*ffmpeg.Processor)
+	assert.Equal(t, "/usr/local/bin/ffmpeg", processor.Binary)
+	assert.Equal(t, processor.Encoder(), app.Processors[1])
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"flag"
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/storage/memorystorage"
+	"go.uber.org/zap"
+)
+
+// withMemory with in-memory LRU Result Storage config option
+func withMemory(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+	var (
+		memoryResultStorageSize = fs.Int64("memory-result-storage-size", 0,
+			"Maximum size in bytes for in-memory LRU Result Storage, placed in front of other result storages. Enable Memory Result Storage only if this value present")
+
+		_, _ = cb()
+	)
+	return func(app *imagor.Imagor) {
+		if *memoryResultStorageSize > 0 {
+			// activate Memory Result Storage only if size config presents,
+			// prepended as the fastest tier of result storages
+			app.ResultStorages = append([]imagor.Storage{
+				memorystorage.New(*memoryResultStorageSize),
+			}, app.ResultStorages...)
+		}
+	}
+}
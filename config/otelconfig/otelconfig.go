@@ -0,0 +1,58 @@
+package otelconfig
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/metrics/oteltracing"
+	"go.uber.org/zap"
+)
+
+// WithOTel with OpenTelemetry tracing config option
+func WithOTel(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+	var (
+		otelTracingEndpoint = fs.String("otel-tracing-endpoint", "",
+			"OpenTelemetry OTLP/HTTP collector endpoint host and port for tracing e.g. localhost:4318. Enable tracing only if this value present")
+		otelTracingURLPath = fs.String("otel-tracing-url-path", "",
+			"OpenTelemetry OTLP/HTTP collector URL path. Default /v1/traces")
+		otelTracingInsecure = fs.Bool("otel-tracing-insecure", false,
+			"OpenTelemetry OTLP/HTTP collector uses HTTP instead of HTTPS")
+		otelTracingHeaders = fs.String("otel-tracing-headers", "",
+			"OpenTelemetry OTLP/HTTP collector request headers in csv of key=value e.g. api-key=abcd,tenant=foo")
+		otelTracingServiceName = fs.String("otel-tracing-service-name", "imagor",
+			"OpenTelemetry tracing service name")
+		otelTracingSampleRatio = fs.Float64("otel-tracing-sample-ratio", 1,
+			"OpenTelemetry tracing sample ratio from 0 to 1, respects sampling decision of incoming traceparent")
+
+		logger, _ = cb()
+	)
+	return func(app *imagor.Imagor) {
+		if *otelTracingEndpoint == "" {
+			return
+		}
+		// activate tracing only if endpoint config presents
+		app.Tracer = oteltracing.New(
+			oteltracing.WithEndpoint(*otelTracingEndpoint),
+			oteltracing.WithURLPath(*otelTracingURLPath),
+			oteltracing.WithInsecure(*otelTracingInsecure),
+			oteltracing.WithHeaders(parseHeaders(*otelTracingHeaders)),
+			oteltracing.WithServiceName(*otelTracingServiceName),
+			oteltracing.WithSampleRatio(*otelTracingSampleRatio),
+			oteltracing.WithLogger(logger),
+		)
+	}
+}
+
+func parseHeaders(s string) map[string]string {
+	var headers map[string]string
+	for _, kv := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.TrimSpace(k) != "" {
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,35 @@
+package otelconfig
+
+import (
+	"testing"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/config"
+	"github.com/cshum/imagor/metrics/oteltracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTelEmpty(t *testing.T) {
+	srv := config.CreateServer([]string{}, WithOTel)
+	app := srv.App.(*imagor.Imagor)
+	assert.Nil(t, app.Tracer)
+}
+
+func TestOTelTracing(t *testing.T) {
+	srv := config.CreateServer([]string{
+		"-otel-tracing-endpoint", "localhost:4318",
+		"-otel-tracing-url-path", "/foo/traces",
+		"-otel-tracing-insecure",
+		"-otel-tracing-headers", "api-key=abcd, tenant=foo,bar",
+		"-otel-tracing-service-name", "my-imagor",
+		"-otel-tracing-sample-ratio", "0.25",
+	}, WithOTel)
+	app := srv.App.(*imagor.Imagor)
+	tracer := app.Tracer.(*oteltracing.OTelTracing)
+	assert.Equal(t, "localhost:4318", tracer.Endpoint)
+	assert.Equal(t, "/foo/traces", tracer.URLPath)
+	assert.True(t, tracer.Insecure)
+	assert.Equal(t, map[string]string{"api-key": "abcd", "tenant": "foo"}, tracer.Headers)
+	assert.Equal(t, "my-imagor", tracer.ServiceName)
+	assert.Equal(t, 0.25, tracer.SampleRatio)
+}
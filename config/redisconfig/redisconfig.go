@@ -0,0 +1,51 @@
+package redisconfig
+
+import (
+	"flag"
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/storage/redisstorage"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// WithRedis with Redis Result Storage config option
+func WithRedis(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+	var (
+		redisResultStorageAddr = fs.String("redis-result-storage-addr", "",
+			"Redis address for Redis Result Storage, accept csv for cluster or sentinel e.g. host1:6379,host2:6379. Enable Redis Result Storage only if this value present")
+		redisResultStorageUsername = fs.String("redis-result-storage-username", "",
+			"Redis username for Redis Result Storage")
+		redisResultStoragePassword = fs.String("redis-result-storage-password", "",
+			"Redis password for Redis Result Storage")
+		redisResultStorageDB = fs.Int("redis-result-storage-db", 0,
+			"Redis database for Redis Result Storage")
+		redisResultStorageMasterName = fs.String("redis-result-storage-master-name", "",
+			"Redis sentinel master name for Redis Result Storage")
+		redisResultStoragePrefix = fs.String("redis-result-storage-prefix", "imagor:",
+			"Redis key prefix for Redis Result Storage")
+		redisResultStorageExpiration = fs.Duration("redis-result-storage-expiration", 0,
+			"Redis Result Storage key TTL expiration duration e.g. 24h. Default no expiration")
+
+		_, _ = cb()
+	)
+	return func(app *imagor.Imagor) {
+		if *redisResultStorageAddr == "" {
+			return
+		}
+		// activate Redis Result Storage only if address config presents
+		client := redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs:      strings.Split(*redisResultStorageAddr, ","),
+			Username:   *redisResultStorageUsername,
+			Password:   *redisResultStoragePassword,
+			DB:         *redisResultStorageDB,
+			MasterName: *redisResultStorageMasterName,
+		})
+		app.ResultStorages = append(app.ResultStorages,
+			redisstorage.New(client,
+				redisstorage.WithPrefix(*redisResultStoragePrefix),
+				redisstorage.WithExpiration(*redisResultStorageExpiration),
+			),
+		)
+	}
+}
@@ -0,0 +1,36 @@
+package redisconfig
+
+import (
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/config"
+	"github.com/cshum/imagor/storage/redisstorage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRedisEmpty(t *testing.T) {
+	srv := config.CreateServer([]string{}, WithRedis)
+	app := srv.App.(*imagor.Imagor)
+	assert.Equal(t, 1, len(app.Loaders))
+	assert.Empty(t, app.Storages)
+	assert.Empty(t, app.ResultStorages)
+}
+
+func TestRedisResultStorage(t *testing.T) {
+	srv := config.CreateServer([]string{
+		"-redis-result-storage-addr", "localhost:6379",
+		"-redis-result-storage-db", "2",
+		"-redis-result-storage-prefix", "abcd:",
+		"-redis-result-storage-expiration", "24h",
+	}, WithRedis)
+	app := srv.App.(*imagor.Imagor)
+	assert.Empty(t, app.Storages)
+	resultStorage := app.ResultStorages[0].(*redisstorage.RedisStorage)
+	assert.Equal(t, "abcd:", resultStorage.Prefix)
+	assert.Equal(t, time.Hour*24, resultStorage.Expiration)
+	client := resultStorage.Client.(*redis.Client)
+	assert.Equal(t, "localhost:6379", client.Options().Addr)
+	assert.Equal(t, 2, client.Options().DB)
+}
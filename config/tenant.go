@@ -0,0 +1,189 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/server"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// tenantConfig tenant config entry of tenants file
+type tenantConfig struct {
+	Hosts      []string          `yaml:"hosts"`
+	PathPrefix string            `yaml:"path_prefix"`
+	Config     map[string]string `yaml:"config"`
+}
+
+// sharedFlags flags shared with the parent imagor that tenant config cannot override
+var sharedFlags = []string{"imagor-process-concurrency", "imagor-process-queue-size"}
+
+// tenantBaseConfig base config of tenant imagor, without memory result storage
+// that would otherwise multiply the memory limit by number of tenants
+var tenantBaseConfig = []Option{
+	withFileSystem,
+	withHTTPLoader,
+}
+
+// processorOptions tracks the Options that add processors to imagor,
+// and the flags registered by them
+type processorOptions struct {
+	funcs map[int]bool
+	flags map[string]bool
+}
+
+func newProcessorOptions() *processorOptions {
+	return &processorOptions{
+		funcs: map[int]bool{},
+		flags: map[string]bool{},
+	}
+}
+
+// track wraps Options to record the ones that add processors to imagor
+func (p *processorOptions) track(funcs []Option) []Option {
+	var tracked = make([]Option, len(funcs))
+	for i, fn := range funcs {
+		i, fn := i, fn
+		if fn == nil {
+			continue
+		}
+		tracked[i] = func(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
+			var seen = map[string]bool{}
+			fs.VisitAll(func(f *flag.Flag) {
+				seen[f.Name] = true
+			})
+			var flags []string
+			var collected bool
+			collect := func() {
+				// flags registered by the Option before callback
+				fs.VisitAll(func(f *flag.Flag) {
+					if !seen[f.Name] {
+						flags = append(flags, f.Name)
+					}
+				})
+				collected = true
+			}
+			option := fn(fs, func() (*zap.Logger, bool) {
+				collect()
+				return cb()
+			})
+			if !collected {
+				collect()
+			}
+			return func(app *imagor.Imagor) {
+				var n = len(app.Processors)
+				option(app)
+				if len(app.Processors) != n {
+					p.funcs[i] = true
+					for _, name := range flags {
+						p.flags[name] = true
+					}
+				}
+			}
+		}
+	}
+	return tracked
+}
+
+// exclude returns Options excluding the ones that added processors
+func (p *processorOptions) exclude(funcs []Option) (result []Option) {
+	for i, fn := range funcs {
+		if !p.funcs[i] {
+			result = append(result, fn)
+		}
+	}
+	return
+}
+
+// isShared returns true if flag is shared with the parent imagor
+func (p *processorOptions) isShared(name string) bool {
+	for _, key := range sharedFlags {
+		if key == name {
+			return true
+		}
+	}
+	return p.flags[name] || strings.HasPrefix(name, "memory-")
+}
+
+// newTenants create tenants from YAML or JSON tenants file of tenant name to tenant config.
+// Tenant imagor is configured by the flags of parent FlagSet, overridden by the tenant config of flag names,
+// while sharing processors, tracer and metrics of the parent imagor.
+// Options that added processors to the parent imagor are excluded from tenants,
+// such that processors are created once and go through the lifecycle of the parent imagor.
+// Imagor lifecycle only concerns processors and tracer, hence tenant imagor has no lifecycle on its own
+func newTenants(
+	file string, parent *flag.FlagSet, app *imagor.Imagor, processors *processorOptions,
+	logger *zap.Logger, isDebug bool, funcs ...Option,
+) ([]server.Tenant, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var configs map[string]tenantConfig
+	// YAML is a superset of JSON
+	if err := yaml.Unmarshal(buf, &configs); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// process concurrency and queue are shared with the parent imagor,
+	// such that tenants sharing the processors are limited as a whole
+	funcs = append(processors.exclude(funcs), func(_ *flag.FlagSet, _ func() (*zap.Logger, bool)) imagor.Option {
+		return imagor.WithSharedProcessQueue(app)
+	})
+	var tenants []server.Tenant
+	for _, name := range names {
+		cfg := configs[name]
+		var keys []string
+		for key := range cfg.Config {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if processors.isShared(key) {
+				return nil, fmt.Errorf("tenant %s: %s is shared and cannot be overridden", name, key)
+			}
+		}
+		var fs = flag.NewFlagSet("imagor-tenant-"+name, flag.ContinueOnError)
+		var tenantErr error
+		var tenantProcessors = newProcessorOptions()
+		tenantApp := newImagor(fs, func() (*zap.Logger, bool) {
+			fs.VisitAll(func(f *flag.Flag) {
+				if pf := parent.Lookup(f.Name); pf != nil && pf.Value.String() != pf.DefValue {
+					_ = f.Value.Set(pf.Value.String())
+				}
+			})
+			for key, value := range cfg.Config {
+				if err := fs.Set(key, value); err != nil && tenantErr == nil {
+					tenantErr = fmt.Errorf("tenant %s: %w", name, err)
+				}
+			}
+			return logger, isDebug
+		}, tenantBaseConfig, tenantProcessors.track(funcs)...)
+		if tenantErr != nil {
+			return nil, tenantErr
+		}
+		if len(tenantProcessors.funcs) > 0 {
+			return nil, fmt.Errorf("tenant %s: processors are shared and cannot be added by tenant", name)
+		}
+		// share processors lifecycle of the parent imagor
+		tenantApp.Processors = app.Processors
+		tenantApp.Tracer = app.Tracer
+		tenantApp.Metrics = app.Metrics
+		tenants = append(tenants, server.Tenant{
+			Name:       name,
+			Hosts:      cfg.Hosts,
+			PathPrefix: cfg.PathPrefix,
+			Handler:    tenantApp,
+		})
+	}
+	return tenants, nil
+}
@@ -3,6 +3,7 @@ package vipsconfig
 import (
 	"flag"
 	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/detector/pigodetector"
 	"github.com/cshum/imagor/vips"
 	"go.uber.org/zap"
 )
@@ -34,9 +35,15 @@ func WithVips(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
 			"VIPS max image resolution")
 		vipsMozJPEG = fs.Bool("vips-mozjpeg", false,
 			"VIPS enable maximum compression with MozJPEG. Requires mozjpeg to be installed")
+		vipsFaceDetection = fs.Bool("vips-face-detection", false,
+			"VIPS enable face detection for smart crop, falls back to attention based smart crop if no face detected")
 
 		logger, isDebug = cb()
+		detector        vips.Detector
 	)
+	if *vipsFaceDetection {
+		detector = pigodetector.New()
+	}
 	return imagor.WithProcessors(
 		vips.NewProcessor(
 			vips.WithMaxAnimationFrames(*vipsMaxAnimationFrames),
@@ -51,6 +58,7 @@ func WithVips(fs *flag.FlagSet, cb func() (*zap.Logger, bool)) imagor.Option {
 			vips.WithMaxHeight(*vipsMaxHeight),
 			vips.WithMaxResolution(*vipsMaxResolution),
 			vips.WithMozJPEG(*vipsMozJPEG),
+			vips.WithDetector(detector),
 			vips.WithLogger(logger),
 			vips.WithDebug(isDebug),
 		),
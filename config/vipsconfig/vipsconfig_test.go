@@ -3,6 +3,7 @@ package vipsconfig
 import (
 	"github.com/cshum/imagor"
 	"github.com/cshum/imagor/config"
+	"github.com/cshum/imagor/detector/pigodetector"
 	"github.com/cshum/imagor/vips"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -17,4 +18,14 @@ func TestWithVips(t *testing.T) {
 	processor := app.Processors[0].(*vips.Processor)
 	assert.Equal(t, 167, processor.MaxAnimationFrames)
 	assert.Equal(t, []string{"blur", "watermark", "rgb"}, processor.DisableFilters)
+	assert.Nil(t, processor.Detector)
+}
+
+func TestWithVipsFaceDetection(t *testing.T) {
+	srv := config.CreateServer([]string{
+		"-vips-face-detection",
+	}, WithVips)
+	app := srv.App.(*imagor.Imagor)
+	processor := app.Processors[0].(*vips.Processor)
+	assert.IsType(t, &pigodetector.PigoDetector{}, processor.Detector)
 }
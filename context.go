@@ -13,6 +13,9 @@ type contextKey struct {
 
 var imagorContextKey = contextKey{1}
 var detachContextKey = contextKey{2}
+var tracerContextKey = contextKey{3}
+var warmContextKey = contextKey{4}
+var limitContextKey = contextKey{5}
 
 type imagorContextRef struct {
 	funcs []func()
@@ -96,3 +99,41 @@ func isDetached(ctx context.Context) bool {
 	_, ok := ctx.Value(detachContextKey).(bool)
 	return ok
 }
+
+// withWarm context that waits for result storage save before returning result
+func withWarm(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warmContextKey, true)
+}
+
+// WithWaitSave context that waits for result storage save before returning result,
+// such as warming up result storage ahead of time
+func WithWaitSave(ctx context.Context) context.Context {
+	return withWarm(ctx)
+}
+
+// isWarm returns if context is warming up result storage
+func isWarm(ctx context.Context) bool {
+	_, ok := ctx.Value(warmContextKey).(bool)
+	return ok
+}
+
+// WithProcessLimit context with limit func, which is called on result storage miss before image processing,
+// or before loading source of raw.
+// Request is rejected with the error returned, such as rate limiting that exempts result storage hits
+func WithProcessLimit(ctx context.Context, fn func() error) context.Context {
+	return context.WithValue(ctx, limitContextKey, fn)
+}
+
+// hasProcessLimit returns if context has limit func
+func hasProcessLimit(ctx context.Context) bool {
+	fn, ok := ctx.Value(limitContextKey).(func() error)
+	return ok && fn != nil
+}
+
+// processLimit calls limit func of context if any
+func processLimit(ctx context.Context) error {
+	if fn, ok := ctx.Value(limitContextKey).(func() error); ok && fn != nil {
+		return fn()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package pigodetector
+
+import (
+	"context"
+	_ "embed"
+	"image"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+// facefinder pico face detection cascade, bundled from github.com/esimov/pigo
+//
+//go:embed facefinder
+var facefinder []byte
+
+// PigoDetector pure Go face detector based on pico cascade classifier,
+// implements vips.Detector interface
+type PigoDetector struct {
+	MinSize      int
+	ShiftFactor  float64
+	ScaleFactor  float64
+	IoUThreshold float64
+	MinScore     float32
+
+	classifier *pigo.Pigo
+}
+
+// New create new PigoDetector
+func New(options ...Option) *PigoDetector {
+	d := &PigoDetector{
+		MinSize:      20,
+		ShiftFactor:  0.1,
+		ScaleFactor:  1.1,
+		IoUThreshold: 0.2,
+		MinScore:     5,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	classifier, err := pigo.NewPigo().Unpack(facefinder)
+	if err != nil {
+		// bundled cascade should always be valid
+		panic(err)
+	}
+	d.classifier = classifier
+	return d
+}
+
+// Detect detects faces of image and returns the face rectangles
+func (d *PigoDetector) Detect(ctx context.Context, img image.Image) ([]image.Rectangle, error) {
+	bounds := img.Bounds()
+	cols, rows := bounds.Dx(), bounds.Dy()
+	maxSize := cols
+	if rows < maxSize {
+		maxSize = rows
+	}
+	if maxSize < d.MinSize {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	detections := d.classifier.RunCascade(pigo.CascadeParams{
+		MinSize:     d.MinSize,
+		MaxSize:     maxSize,
+		ShiftFactor: d.ShiftFactor,
+		ScaleFactor: d.ScaleFactor,
+		ImageParams: pigo.ImageParams{
+			Pixels: grayscale(img),
+			Rows:   rows,
+			Cols:   cols,
+			Dim:    cols,
+		},
+	}, 0)
+	detections = d.classifier.ClusterDetections(detections, d.IoUThreshold)
+	var rects []image.Rectangle
+	for _, det := range detections {
+		if det.Q < d.MinScore {
+			continue
+		}
+		half := det.Scale / 2
+		rect := image.Rect(
+			det.Col-half, det.Row-half, det.Col+half, det.Row+half,
+		).Add(bounds.Min).Intersect(bounds)
+		if !rect.Empty() {
+			rects = append(rects, rect)
+		}
+	}
+	return rects, nil
+}
+
+// grayscale returns luma pixels of image in row-major order
+func grayscale(img image.Image) []uint8 {
+	bounds := img.Bounds()
+	if gray, ok := img.(*image.Gray); ok && gray.Stride == bounds.Dx() {
+		return gray.Pix
+	}
+	var (
+		cols   = bounds.Dx()
+		pixels = make([]uint8, cols*bounds.Dy())
+	)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels[(y-bounds.Min.Y)*cols+(x-bounds.Min.X)] = uint8(
+				(19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+		}
+	}
+	return pixels
+}
+
+// Option PigoDetector option
+type Option func(d *PigoDetector)
+
+// WithMinSize with minimum face size in pixels option
+func WithMinSize(size int) Option {
+	return func(d *PigoDetector) {
+		if size > 0 {
+			d.MinSize = size
+		}
+	}
+}
+
+// WithMinScore with minimum detection score option, higher for less false positives
+func WithMinScore(score float64) Option {
+	return func(d *PigoDetector) {
+		if score > 0 {
+			d.MinScore = float32(score)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pigodetector
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/bmp"
+)
+
+var testDataDir string
+
+func init() {
+	_, b, _, _ := runtime.Caller(0)
+	testDataDir = filepath.Join(filepath.Dir(b), "../../testdata")
+}
+
+func TestDetect(t *testing.T) {
+	f, err := os.Open(filepath.Join(testDataDir, "lena_gray.bmp"))
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := bmp.Decode(f)
+	require.NoError(t, err)
+
+	d := New(WithMinSize(30), WithMinScore(5))
+	assert.Equal(t, 30, d.MinSize)
+	rects, err := d.Detect(context.Background(), img)
+	require.NoError(t, err)
+	require.Len(t, rects, 1)
+	face := rects[0]
+	assert.True(t, face.In(img.Bounds()))
+	// face around the center of the image
+	center := image.Pt((face.Min.X+face.Max.X)/2, (face.Min.Y+face.Max.Y)/2)
+	assert.True(t, center.In(image.Rect(200, 200, 380, 380)), center)
+	assert.True(t, face.Dx() > 80 && face.Dx() < 250, face)
+
+	// sub image with offset bounds and non gray image
+	rgba := image.NewRGBA(image.Rect(100, 100, 612, 612))
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
+	rects, err = d.Detect(context.Background(), rgba)
+	require.NoError(t, err)
+	require.Len(t, rects, 1)
+	assert.Equal(t, face.Add(image.Pt(100, 100)), rects[0])
+}
+
+func TestDetectNoFace(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 200))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{Y: 128}), image.Point{}, draw.Src)
+	d := New()
+	rects, err := d.Detect(context.Background(), img)
+	assert.NoError(t, err)
+	assert.Empty(t, rects)
+
+	rects, err = d.Detect(context.Background(), image.NewGray(image.Rect(0, 0, 10, 10)))
+	assert.NoError(t, err)
+	assert.Empty(t, rects, "smaller than min size")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = d.Detect(ctx, img)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -18,6 +18,8 @@ var (
 	ErrInvalid = NewError("invalid", http.StatusBadRequest)
 	// ErrMethodNotAllowed method not allowed error
 	ErrMethodNotAllowed = NewError("method not allowed", http.StatusMethodNotAllowed)
+	// ErrUnauthorized unauthorized error
+	ErrUnauthorized = NewError("unauthorized", http.StatusUnauthorized)
 	// ErrSignatureMismatch URL signature mismatch error
 	ErrSignatureMismatch = NewError("url signature mismatch", http.StatusForbidden)
 	// ErrTimeout timeout error
@@ -32,6 +34,8 @@ var (
 	ErrMaxResolutionExceeded = NewError("maximum resolution exceeded", http.StatusUnprocessableEntity)
 	// ErrTooManyRequests too many requests error
 	ErrTooManyRequests = NewError("too many requests", http.StatusTooManyRequests)
+	// ErrNotImplemented not implemented error
+	ErrNotImplemented = NewError("not implemented", http.StatusNotImplemented)
 	// ErrInternal internal error
 	ErrInternal = NewError("internal error", http.StatusInternalServerError)
 )
@@ -0,0 +1,104 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"go.uber.org/zap"
+)
+
+// Encoder encodes animated GIF forwarded by the previous processor e.g. vips
+// to MP4 video for format(mp4), by piping to ffmpeg binary.
+// Other animated formats such as WebP are expected to be converted to GIF by vips
+type Encoder struct {
+	*Processor
+}
+
+// Encoder returns Encoder of the Processor,
+// which should be placed after the image processor
+func (p *Processor) Encoder() *Encoder {
+	return &Encoder{Processor: p}
+}
+
+// Startup implements imagor.Processor interface
+func (e *Encoder) Startup(_ context.Context) error {
+	return nil
+}
+
+// Process implements imagor.Processor interface
+func (e *Encoder) Process(
+	ctx context.Context, blob *imagor.Blob, params imagorpath.Params, _ imagor.LoadFunc,
+) (*imagor.Blob, error) {
+	if blob == nil || blob.BlobType() != imagor.BlobTypeGIF || !isVideoFormat(params) {
+		return nil, imagor.ErrForward{Params: params}
+	}
+	ctx, span := imagor.StartSpan(ctx, "ffmpeg.encode")
+	buf, err := e.encode(ctx, blob)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	if e.Debug {
+		e.Logger.Debug("encode", zap.Int("size", len(buf)))
+	}
+	return imagor.NewBlobFromBytes(buf), nil
+}
+
+// encode pipes GIF to ffmpeg and encodes H.264 MP4 with even dimensions.
+// Output is written to temp file such that moov atom can be placed at the front
+func (e *Encoder) encode(ctx context.Context, blob *imagor.Blob) ([]byte, error) {
+	reader, _, err := blob.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	dir, err := os.MkdirTemp("", "imagor-ffmpeg-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	output := filepath.Join(dir, "output.mp4")
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Binary,
+		"-hide_banner", "-loglevel", "error",
+		"-f", "gif", "-i", "pipe:0",
+		"-an", "-c:v", "libx264", "-pix_fmt", "yuv420p",
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",
+		"-movflags", "+faststart", "-f", "mp4", "-y", output,
+	)
+	cmd.Stdin = reader
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, imagor.NewError(fmt.Sprintf("ffmpeg: %s", msg), http.StatusNotAcceptable)
+	}
+	return os.ReadFile(output)
+}
+
+// isVideoFormat checks if params specify video output format
+func isVideoFormat(params imagorpath.Params) (ok bool) {
+	for _, filter := range params.Filters {
+		if filter.Name == "format" {
+			ok = filter.Args == "mp4"
+		}
+	}
+	return
+}
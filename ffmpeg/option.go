@@ -0,0 +1,31 @@
+package ffmpeg
+
+import "go.uber.org/zap"
+
+// Option ffmpeg Processor option
+type Option func(p *Processor)
+
+// WithBinary with ffmpeg binary name or path option, looked up from PATH
+func WithBinary(binary string) Option {
+	return func(p *Processor) {
+		if binary != "" {
+			p.Binary = binary
+		}
+	}
+}
+
+// WithLogger with logger option
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Processor) {
+		if logger != nil {
+			p.Logger = logger
+		}
+	}
+}
+
+// WithDebug with debug option
+func WithDebug(debug bool) Option {
+	return func(p *Processor) {
+		p.Debug = debug
+	}
+}
@@ -0,0 +1,154 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"go.uber.org/zap"
+)
+
+var timestampRegex = regexp.MustCompile(`^\d+(:\d{1,2}){1,2}(\.\d+)?$`)
+
+// Processor extracts video frame by piping to ffmpeg binary,
+// forwarding the extracted image to the next processor e.g. vips
+type Processor struct {
+	Binary string
+	Logger *zap.Logger
+	Debug  bool
+}
+
+// NewProcessor create ffmpeg Processor
+func NewProcessor(options ...Option) *Processor {
+	p := &Processor{
+		Binary: "ffmpeg",
+		Logger: zap.NewNop(),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Startup implements imagor.Processor interface,
+// checks if ffmpeg binary exists
+func (p *Processor) Startup(_ context.Context) error {
+	path, err := exec.LookPath(p.Binary)
+	if err != nil {
+		return err
+	}
+	p.Binary = path
+	p.Logger.Info("ffmpeg", zap.String("binary", path))
+	return nil
+}
+
+// Shutdown implements imagor.Processor interface
+func (p *Processor) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Process implements imagor.Processor interface.
+// Video blob is replaced by the frame at timestamp of frame(offset) filter,
+// then forwarded to the next processor. Others are forwarded as is.
+func (p *Processor) Process(
+	ctx context.Context, blob *imagor.Blob, params imagorpath.Params, _ imagor.LoadFunc,
+) (*imagor.Blob, error) {
+	if blob == nil || !blob.IsVideo() {
+		return nil, imagor.ErrForward{Params: params}
+	}
+	var offset = "0"
+	var filters imagorpath.Filters
+	for _, filter := range params.Filters {
+		if filter.Name == "frame" {
+			offset = parseOffset(filter.Args)
+			continue
+		}
+		filters = append(filters, filter)
+	}
+	params.Filters = filters
+	ctx, span := imagor.StartSpan(ctx, "ffmpeg.frame")
+	span.SetAttribute("ffmpeg.offset", offset)
+	buf, err := p.extractFrame(ctx, blob, offset)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	if p.Debug {
+		p.Logger.Debug("frame", zap.String("offset", offset), zap.Int("size", len(buf)))
+	}
+	return imagor.NewBlobFromBytes(buf), imagor.ErrForward{Params: params}
+}
+
+// extractFrame extracts single frame as PNG from ffmpeg output pipe.
+// Source is written to temp file since MP4 requires seekable input
+func (p *Processor) extractFrame(ctx context.Context, blob *imagor.Blob, offset string) ([]byte, error) {
+	file, err := os.CreateTemp("", "imagor-ffmpeg-*."+strings.TrimPrefix(blob.ContentType(), "video/"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	reader, _, err := blob.NewReader()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	_, err = io.Copy(file, reader)
+	_ = reader.Close()
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Binary,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-ss", offset, "-i", file.Name(),
+		"-frames:v", "1", "-f", "image2pipe", "-c:v", "png", "pipe:1",
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, imagor.NewError(fmt.Sprintf("ffmpeg: %s", msg), http.StatusNotAcceptable)
+	}
+	if stdout.Len() == 0 {
+		return nil, imagor.NewError(
+			fmt.Sprintf("ffmpeg: no frame at offset %s", offset), http.StatusNotFound)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseOffset parses frame timestamp e.g. 5s, 1m30s, 1.5, 00:01:30
+// to ffmpeg time duration, defaults to 0
+func parseOffset(s string) string {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && f >= 0 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if timestampRegex.MatchString(s) {
+		return s
+	}
+	return "0"
+}
@@ -0,0 +1,222 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mp4Header = []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2avc1mp41")
+
+// fakeBinary creates shell script as ffmpeg binary,
+// writing its arguments to args file
+func fakeBinary(t *testing.T, script string) (binary, argsFile string) {
+	dir := t.TempDir()
+	binary = filepath.Join(dir, "ffmpeg")
+	argsFile = filepath.Join(dir, "args")
+	require.NoError(t, os.WriteFile(binary, []byte(fmt.Sprintf(
+		"#!/bin/sh\necho \"$@\" > %s\n%s\n", argsFile, script)), 0755))
+	return
+}
+
+func newVideoBlob() *imagor.Blob {
+	return imagor.NewBlobFromBytes(append(mp4Header, make([]byte, 64)...))
+}
+
+func TestProcessor(t *testing.T) {
+	gopher, err := filepath.Abs("../testdata/gopher.png")
+	require.NoError(t, err)
+	binary, argsFile := fakeBinary(t, "cat "+gopher)
+	p := NewProcessor(WithBinary(binary), WithDebug(true))
+	require.NoError(t, p.Startup(context.Background()))
+
+	t.Run("frame", func(t *testing.T) {
+		params := imagorpath.Parse("100x100/filters:frame(1m5s):grayscale()/video.mp4")
+		blob, err := p.Process(context.Background(), newVideoBlob(), params, nil)
+		require.IsType(t, imagor.ErrForward{}, err)
+		assert.Equal(t, imagor.BlobTypePNG, blob.BlobType())
+		forward := err.(imagor.ErrForward)
+		assert.Equal(t, imagorpath.Filters{{Name: "grayscale"}}, forward.Filters)
+		assert.Equal(t, 100, forward.Width)
+		args, err := os.ReadFile(argsFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(args), "-ss 65 -i ")
+		assert.Contains(t, string(args), "-frames:v 1")
+	})
+
+	t.Run("default offset", func(t *testing.T) {
+		params := imagorpath.Parse("100x100/video.mp4")
+		blob, err := p.Process(context.Background(), newVideoBlob(), params, nil)
+		require.IsType(t, imagor.ErrForward{}, err)
+		assert.Equal(t, imagor.BlobTypePNG, blob.BlobType())
+		args, err := os.ReadFile(argsFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(args), "-ss 0 -i ")
+	})
+
+	t.Run("non video forward", func(t *testing.T) {
+		params := imagorpath.Parse("100x100/filters:frame(5s)/gopher.png")
+		blob, err := p.Process(context.Background(), imagor.NewBlobFromFile(gopher), params, nil)
+		assert.Nil(t, blob)
+		assert.Equal(t, imagor.ErrForward{Params: params}, err)
+	})
+}
+
+func TestProcessorError(t *testing.T) {
+	binary, _ := fakeBinary(t, "echo 'invalid data found' >&2\nexit 1")
+	p := NewProcessor(WithBinary(binary))
+	_, err := p.Process(context.Background(), newVideoBlob(), imagorpath.Parse("video.mp4"), nil)
+	assert.Equal(t, imagor.NewError("ffmpeg: invalid data found", http.StatusNotAcceptable), err)
+
+	binary, _ = fakeBinary(t, "exit 0")
+	p = NewProcessor(WithBinary(binary))
+	_, err = p.Process(context.Background(), newVideoBlob(), imagorpath.Parse("filters:frame(99s)/video.mp4"), nil)
+	assert.Equal(t, imagor.NewError("ffmpeg: no frame at offset 99", http.StatusNotFound), err)
+
+	p = NewProcessor(WithBinary("imagor-ffmpeg-not-exists"))
+	assert.Error(t, p.Startup(context.Background()))
+}
+
+func TestProcessorChain(t *testing.T) {
+	gopher, err := filepath.Abs("../testdata/gopher.png")
+	require.NoError(t, err)
+	binary, _ := fakeBinary(t, "cat "+gopher)
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return newVideoBlob(), nil
+		})),
+		imagor.WithProcessors(
+			NewProcessor(WithBinary(binary)),
+			processorFunc(func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+				if blob.BlobType() != imagor.BlobTypePNG {
+					return nil, imagor.ErrUnsupportedFormat
+				}
+				return imagor.NewBlobFromBytes([]byte(imagorpath.GeneratePath(p))), nil
+			}),
+		),
+	)
+	require.NoError(t, app.Startup(context.Background()))
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "https://example.com/unsafe/200x0/filters:frame(5s):blur(2)/video.mp4", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "200x0/filters:blur(2)/video.mp4", w.Body.String())
+}
+
+type loaderFunc func(r *http.Request, image string) (*imagor.Blob, error)
+
+func (f loaderFunc) Get(r *http.Request, image string) (*imagor.Blob, error) {
+	return f(r, image)
+}
+
+type processorFunc func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error)
+
+func (f processorFunc) Process(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+	return f(ctx, blob, p, load)
+}
+
+func (f processorFunc) Startup(_ context.Context) error {
+	return nil
+}
+
+func (f processorFunc) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestParseOffset(t *testing.T) {
+	for in, out := range map[string]string{
+		"5s":          "5",
+		"1m30s":       "90",
+		"500ms":       "0.5",
+		"1.5":         "1.5",
+		"10":          "10",
+		"00:01:30":    "00:01:30",
+		"01:30.5":     "01:30.5",
+		"":            "0",
+		"-5s":         "0",
+		"abc":         "0",
+		"1;rm -rf /":  "0",
+		" 2s ":        "2",
+		"00:00:05.25": "00:00:05.25",
+	} {
+		assert.Equal(t, out, parseOffset(in), in)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	gif, err := os.ReadFile("../testdata/dancing-banana.gif")
+	require.NoError(t, err)
+	// copies stdin to the output file of last argument
+	binary, argsFile := fakeBinary(t, "for last; do true; done\ncat > \"$last\"")
+	e := NewProcessor(WithBinary(binary), WithDebug(true)).Encoder()
+	require.NoError(t, e.Startup(context.Background()))
+
+	blob, err := e.Process(context.Background(), imagor.NewBlobFromBytes(gif),
+		imagorpath.Parse("filters:format(mp4)/foo.gif"), nil)
+	require.NoError(t, err)
+	buf, err := blob.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, gif, buf)
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "-f gif -i pipe:0")
+	assert.Contains(t, string(args), "-c:v libx264")
+
+	for _, path := range []string{
+		"filters:format(webp)/foo.gif",
+		"filters:format(mp4):format(gif)/foo.gif",
+		"foo.gif",
+	} {
+		params := imagorpath.Parse(path)
+		blob, err = e.Process(context.Background(), imagor.NewBlobFromBytes(gif), params, nil)
+		assert.Nil(t, blob)
+		assert.Equal(t, imagor.ErrForward{Params: params}, err, path)
+	}
+	params := imagorpath.Parse("filters:format(mp4)/foo.mp4")
+	blob, err = e.Process(context.Background(), newVideoBlob(), params, nil)
+	assert.Nil(t, blob)
+	assert.Equal(t, imagor.ErrForward{Params: params}, err)
+
+	binary, _ = fakeBinary(t, "echo 'encoder not found' >&2\nexit 1")
+	e = NewProcessor(WithBinary(binary)).Encoder()
+	_, err = e.Process(context.Background(), imagor.NewBlobFromBytes(gif),
+		imagorpath.Parse("filters:format(mp4)/foo.gif"), nil)
+	assert.Equal(t, imagor.NewError("ffmpeg: encoder not found", http.StatusNotAcceptable), err)
+}
+
+func TestEncoderChain(t *testing.T) {
+	gif, err := os.ReadFile("../testdata/dancing-banana.gif")
+	require.NoError(t, err)
+	binary, _ := fakeBinary(t, "for last; do true; done\n(printf '\\000\\000\\000\\040ftypisom'; cat) > \"$last\"")
+	p := NewProcessor(WithBinary(binary))
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return imagor.NewBlobFromBytes(gif), nil
+		})),
+		imagor.WithProcessors(
+			p,
+			processorFunc(func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+				// image processor forwards gif for video format
+				return blob, imagor.ErrForward{Params: p}
+			}),
+			p.Encoder(),
+		),
+	)
+	require.NoError(t, app.Startup(context.Background()))
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "https://example.com/unsafe/filters:format(mp4)/foo.gif", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "video/mp4", w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,104 @@
+package imagemeta
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+type colorBox struct {
+	pixels [][3]uint8
+}
+
+// rangeOf returns the widest channel and its range of the box
+func (b *colorBox) rangeOf() (channel int, size int) {
+	for c := 0; c < 3; c++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, p := range b.pixels {
+			if p[c] < lo {
+				lo = p[c]
+			}
+			if p[c] > hi {
+				hi = p[c]
+			}
+		}
+		if int(hi)-int(lo) > size {
+			channel, size = c, int(hi)-int(lo)
+		}
+	}
+	return
+}
+
+func (b *colorBox) average() (r, g, bl uint8) {
+	var sr, sg, sb int
+	for _, p := range b.pixels {
+		sr += int(p[0])
+		sg += int(p[1])
+		sb += int(p[2])
+	}
+	n := len(b.pixels)
+	return uint8((sr + n/2) / n), uint8((sg + n/2) / n), uint8((sb + n/2) / n)
+}
+
+// DominantColors returns up to n dominant colors of image in hex, ordered by population,
+// using median cut quantization. Mostly transparent pixels are ignored
+func DominantColors(img image.Image, n int) []string {
+	if n < 1 {
+		return nil
+	}
+	bounds := img.Bounds()
+	var pixels [][3]uint8
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			// un-premultiply
+			pixels = append(pixels, [3]uint8{
+				uint8(r * 0xff / a), uint8(g * 0xff / a), uint8(b * 0xff / a),
+			})
+		}
+	}
+	if len(pixels) == 0 {
+		return nil
+	}
+	boxes := []*colorBox{{pixels: pixels}}
+	for len(boxes) < n {
+		// split the box of the widest weighted range at median
+		var idx, channel, best = -1, 0, 0
+		for i, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+			c, size := box.rangeOf()
+			if score := size * len(box.pixels); size > 0 && score > best {
+				idx, channel, best = i, c, score
+			}
+		}
+		if idx < 0 {
+			break
+		}
+		box := boxes[idx]
+		sort.Slice(box.pixels, func(i, j int) bool {
+			return box.pixels[i][channel] < box.pixels[j][channel]
+		})
+		mid := len(box.pixels) / 2
+		boxes[idx] = &colorBox{pixels: box.pixels[:mid]}
+		boxes = append(boxes, &colorBox{pixels: box.pixels[mid:]})
+	}
+	sort.SliceStable(boxes, func(i, j int) bool {
+		return len(boxes[i].pixels) > len(boxes[j].pixels)
+	})
+	var colors []string
+	var seen = map[string]bool{}
+	for _, box := range boxes {
+		r, g, b := box.average()
+		hex := fmt.Sprintf("#%02x%02x%02x", r, g, b)
+		if !seen[hex] {
+			seen[hex] = true
+			colors = append(colors, hex)
+		}
+	}
+	return colors
+}
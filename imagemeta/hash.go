@@ -0,0 +1,118 @@
+package imagemeta
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// PHash returns 64 bits perceptual hash of image in hex,
+// based on discrete cosine transform of the luminance.
+// Similar images have hashes of small Hamming distance
+func PHash(img image.Image) string {
+	const size = 32
+	const n = 8
+	var pixels = grayscale(img, size, size)
+	// separable DCT of the top-left n x n low frequencies
+	var rows [size][n]float64
+	for y := 0; y < size; y++ {
+		for u := 0; u < n; u++ {
+			var sum float64
+			for x := 0; x < size; x++ {
+				sum += pixels[y*size+x] * math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*size))
+			}
+			rows[y][u] = sum
+		}
+	}
+	var coeffs = make([]float64, n*n)
+	for v := 0; v < n; v++ {
+		for u := 0; u < n; u++ {
+			var sum float64
+			for y := 0; y < size; y++ {
+				sum += rows[y][u] * math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*size))
+			}
+			coeffs[v*n+u] = sum
+		}
+	}
+	// median excluding the DC term
+	sorted := append([]float64{}, coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(len(coeffs)-1-i)
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// DHash returns 64 bits difference hash of image in hex,
+// based on luminance gradient between adjacent pixels
+func DHash(img image.Image) string {
+	const w, h = 9, 8
+	var pixels = grayscale(img, w, h)
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if pixels[y*w+x] > pixels[y*w+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// grayscale returns luminance of image resized to w x h by area average,
+// with transparent pixels composited over white
+func grayscale(img image.Image, w, h int) []float64 {
+	var pixels = make([]float64, w*h)
+	resample(img, w, h, func(i int, r, g, b float64) {
+		pixels[i] = 0.299*r + 0.587*g + 0.114*b
+	})
+	return pixels
+}
+
+// resample calls fn with the average color of each cell of image divided into w x h,
+// in 0-255 range with transparent pixels composited over white
+func resample(img image.Image, w, h int, fn func(i int, r, g, b float64)) {
+	bounds := img.Bounds()
+	dx, dy := bounds.Dx(), bounds.Dy()
+	for cy := 0; cy < h; cy++ {
+		y0 := bounds.Min.Y + cy*dy/h
+		y1 := bounds.Min.Y + (cy+1)*dy/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for cx := 0; cx < w; cx++ {
+			x0 := bounds.Min.X + cx*dx/w
+			x1 := bounds.Min.X + (cx+1)*dx/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sr, sg, sb, cnt float64
+			for y := y0; y < y1 && y < bounds.Max.Y; y++ {
+				for x := x0; x < x1 && x < bounds.Max.X; x++ {
+					r, g, b := overWhite(img.At(x, y).RGBA())
+					sr += r
+					sg += g
+					sb += b
+					cnt++
+				}
+			}
+			if cnt > 0 {
+				fn(cy*w+cx, sr/cnt, sg/cnt, sb/cnt)
+			} else {
+				fn(cy*w+cx, 255, 255, 255)
+			}
+		}
+	}
+}
+
+// overWhite composites premultiplied color over white in 0-255 range
+func overWhite(r, g, b, a uint32) (float64, float64, float64) {
+	white := float64(0xffff - a)
+	return (float64(r) + white) / 257, (float64(g) + white) / 257, (float64(b) + white) / 257
+}
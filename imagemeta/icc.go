@@ -0,0 +1,59 @@
+package imagemeta
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// ICCProfileName returns profile description of ICC profile data,
+// supports desc tag of ICC v2 textDescriptionType and v4 multiLocalizedUnicodeType
+func ICCProfileName(data []byte) string {
+	if len(data) < 132 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint32(data[128:132]))
+	for i := 0; i < count; i++ {
+		entry := 132 + i*12
+		if entry+12 > len(data) {
+			return ""
+		}
+		if string(data[entry:entry+4]) != "desc" {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4 : entry+8]))
+		size := int(binary.BigEndian.Uint32(data[entry+8 : entry+12]))
+		if offset < 0 || size < 12 || offset+size > len(data) || offset+size < offset {
+			return ""
+		}
+		return parseDesc(data[offset : offset+size])
+	}
+	return ""
+}
+
+func parseDesc(tag []byte) string {
+	switch string(tag[:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:12]))
+		if n <= 0 || 12+n > len(tag) {
+			return ""
+		}
+		return strings.TrimRight(string(tag[12:12+n]), "\x00")
+	case "mluc":
+		if len(tag) < 28 {
+			return ""
+		}
+		// first record of language, country, length and offset
+		length := int(binary.BigEndian.Uint32(tag[20:24]))
+		offset := int(binary.BigEndian.Uint32(tag[24:28]))
+		if length <= 0 || offset+length > len(tag) || offset+length < offset {
+			return ""
+		}
+		units := make([]uint16, length/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+i*2:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package imagemeta
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"os"
+	"strconv"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/draw"
+)
+
+func decode(t *testing.T, name string) image.Image {
+	f, err := os.Open("../testdata/" + name)
+	require.NoError(t, err)
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	require.NoError(t, err)
+	return img
+}
+
+func scale(img image.Image, w, h int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func solid(c color.Color, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func gradient(w, h int, reverse bool) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := x * 255 / (w - 1)
+			if reverse {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return img
+}
+
+func distance(a, b string) int {
+	x, _ := strconv.ParseUint(a, 16, 64)
+	y, _ := strconv.ParseUint(b, 16, 64)
+	return bits.OnesCount64(x ^ y)
+}
+
+func TestHash(t *testing.T) {
+	demo1 := decode(t, "demo1.jpg")
+	demo1Scaled := scale(demo1, 300, 200)
+	gopher := decode(t, "gopher.png")
+
+	for _, fn := range []func(image.Image) string{PHash, DHash} {
+		h1, h2, h3 := fn(demo1), fn(demo1Scaled), fn(gopher)
+		assert.Len(t, h1, 16)
+		assert.Equal(t, h1, fn(demo1), "deterministic")
+		assert.LessOrEqual(t, distance(h1, h2), 6, "similar images")
+		assert.Greater(t, distance(h1, h3), 12, "different images")
+	}
+	assert.Equal(t, "0000000000000000", DHash(gradient(90, 80, false)))
+	assert.Equal(t, "ffffffffffffffff", DHash(gradient(90, 80, true)))
+	// transparent composited over white
+	assert.Equal(t, PHash(solid(color.White, 64, 64)), PHash(solid(color.Transparent, 64, 64)))
+}
+
+func TestDominantColors(t *testing.T) {
+	img := solid(color.RGBA{R: 255, A: 255}, 40, 40)
+	draw.Draw(img, image.Rect(0, 0, 20, 20), image.NewUniform(color.RGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+	assert.Equal(t, []string{"#ff0000", "#0000ff"}, DominantColors(img, 5))
+	assert.Equal(t, []string{"#ff0000"}, DominantColors(solid(color.RGBA{R: 255, A: 255}, 10, 10), 5))
+	assert.Nil(t, DominantColors(solid(color.Transparent, 10, 10), 5))
+	assert.Nil(t, DominantColors(img, 0))
+
+	colors := DominantColors(decode(t, "demo1.jpg"), 5)
+	assert.Len(t, colors, 5)
+	for _, c := range colors {
+		assert.Regexp(t, "^#[0-9a-f]{6}$", c)
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	assert.Equal(t, "L9TSUA~qfQ~q~qoffQoffQfQfQfQ", Blurhash(solid(color.White, 32, 32), 4, 3))
+	assert.Equal(t, "00TI:j", Blurhash(solid(color.RGBA{R: 255, A: 255}, 8, 8), 1, 1))
+	assert.Equal(t, "", Blurhash(image.NewRGBA(image.Rect(0, 0, 0, 0)), 4, 3))
+
+	hash := Blurhash(scale(decode(t, "demo1.jpg"), 32, 21), 4, 3)
+	assert.Len(t, hash, 4+2+2*(4*3-1))
+	assert.Len(t, Blurhash(solid(color.White, 8, 8), 12, 0), 4+2+2*(9*1-1), "clamped components")
+}
+
+func iccProfile(tag []byte) []byte {
+	data := make([]byte, 132+12)
+	binary.BigEndian.PutUint32(data[128:], 1)
+	copy(data[132:], "desc")
+	binary.BigEndian.PutUint32(data[136:], uint32(len(data)))
+	binary.BigEndian.PutUint32(data[140:], uint32(len(tag)))
+	return append(data, tag...)
+}
+
+func TestICCProfileName(t *testing.T) {
+	name := "sRGB IEC61966-2.1"
+	v2 := append([]byte("desc\x00\x00\x00\x00"), make([]byte, 4)...)
+	binary.BigEndian.PutUint32(v2[8:], uint32(len(name)+1))
+	v2 = append(append(v2, name...), 0)
+	assert.Equal(t, name, ICCProfileName(iccProfile(v2)))
+
+	name = "Display P3"
+	units := utf16.Encode([]rune(name))
+	v4 := append([]byte("mluc\x00\x00\x00\x00"), make([]byte, 20)...)
+	binary.BigEndian.PutUint32(v4[8:], 1)
+	binary.BigEndian.PutUint32(v4[12:], 12)
+	copy(v4[16:], "enUS")
+	binary.BigEndian.PutUint32(v4[20:], uint32(len(units)*2))
+	binary.BigEndian.PutUint32(v4[24:], 28)
+	for _, u := range units {
+		v4 = binary.BigEndian.AppendUint16(v4, u)
+	}
+	assert.Equal(t, name, ICCProfileName(iccProfile(v4)))
+
+	assert.Equal(t, "", ICCProfileName(nil))
+	assert.Equal(t, "", ICCProfileName(make([]byte, 200)))
+	broken := iccProfile(v2)
+	binary.BigEndian.PutUint32(broken[136:], 9999)
+	assert.Equal(t, "", ICCProfileName(broken))
+}
@@ -2,6 +2,7 @@ package imagor
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,10 +11,12 @@ import (
 	"golang.org/x/sync/semaphore"
 	"golang.org/x/sync/singleflight"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +26,9 @@ import (
 // Version imagor version
 const Version = "1.4.7"
 
+// DefaultUploadMaxSize default maximum upload request body size in bytes
+const DefaultUploadMaxSize = 32 << 20
+
 // Loader image loader interface
 type Loader interface {
 	Get(r *http.Request, key string) (*Blob, error)
@@ -39,10 +45,16 @@ type Storage interface {
 	// Put data Blob by key
 	Put(ctx context.Context, key string, blob *Blob) error
 
-	// Delete delete data Blob by key
+	// Delete delete data Blob by key,
+	// which may return ErrNotFound if key does not exist
 	Delete(ctx context.Context, key string) error
 }
 
+// PrefixDeleter optional Storage interface for deleting all keys with the given prefix
+type PrefixDeleter interface {
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
 // LoadFunc function handler for Processor to call loader
 type LoadFunc func(string) (*Blob, error)
 
@@ -64,6 +76,7 @@ type Processor interface {
 type Imagor struct {
 	Unsafe                 bool
 	Signer                 imagorpath.Signer
+	KeyVerifier            imagorpath.KeyVerifier
 	StoragePathStyle       imagorpath.StorageHasher
 	ResultStoragePathStyle imagorpath.ResultStorageHasher
 	BasePathRedirect       string
@@ -81,10 +94,20 @@ type Imagor struct {
 	ProcessQueueSize       int64
 	AutoWebP               bool
 	AutoAVIF               bool
+	AutoJXL                bool
+	ClientHints            bool
+	ClientHintsMaxWidth    int
+	ClientHintsMaxHeight   int
 	ModifiedTimeCheck      bool
 	DisableErrorBody       bool
 	DisableParamsEndpoint  bool
+	UploadEnabled          bool
+	UploadMaxSize          int64
+	AdminSecret            string
 	BaseParams             string
+	Presets                imagorpath.Presets
+	Tracer                 Tracer
+	Metrics                Metrics
 	Logger                 *zap.Logger
 	Debug                  bool
 
@@ -104,11 +127,14 @@ func New(options ...Option) *Imagor {
 		ProcessTimeout: time.Second * 20,
 		CacheHeaderTTL: time.Hour * 24 * 7,
 		CacheHeaderSWR: time.Hour * 24,
+
+		ClientHintsMaxWidth:  DefaultClientHintsMaxSize,
+		ClientHintsMaxHeight: DefaultClientHintsMaxSize,
 	}
 	for _, option := range options {
 		option(app)
 	}
-	if app.ProcessConcurrency > 0 {
+	if app.ProcessConcurrency > 0 && app.sema == nil {
 		app.sema = semaphore.NewWeighted(app.ProcessConcurrency)
 		app.queueSema = semaphore.NewWeighted(app.ProcessQueueSize + app.ProcessConcurrency)
 	}
@@ -127,6 +153,11 @@ func New(options ...Option) *Imagor {
 
 // Startup Imagor startup lifecycle
 func (app *Imagor) Startup(ctx context.Context) (err error) {
+	if s, ok := app.Tracer.(interface{ Startup(context.Context) error }); ok {
+		if err = s.Startup(ctx); err != nil {
+			return
+		}
+	}
 	for _, processor := range app.Processors {
 		if err = processor.Startup(ctx); err != nil {
 			return
@@ -142,12 +173,26 @@ func (app *Imagor) Shutdown(ctx context.Context) (err error) {
 			return
 		}
 	}
+	if s, ok := app.Tracer.(interface{ Shutdown(context.Context) error }); ok {
+		// flush pending spans after processors shutdown
+		err = s.Shutdown(ctx)
+	}
 	return
 }
 
 // ServeHTTP implements http.Handler for imagor operations
 func (app *Imagor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+	if r.Method == http.MethodDelete && app.AdminSecret != "" {
+		app.servePurge(w, r)
+		return
+	}
+	if r.Method == http.MethodPost && app.AdminSecret != "" &&
+		strings.HasPrefix(r.URL.Path, "/warm/") {
+		app.serveWarm(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead &&
+		!(app.UploadEnabled && isUpload(r)) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
@@ -161,33 +206,34 @@ func (app *Imagor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	p := imagorpath.Parse(path)
+	p := app.Presets.Parse(path)
 	if p.Params {
 		if !app.DisableParamsEndpoint {
 			writeJSONIndent(w, r, p)
 		}
 		return
 	}
+	if isUpload(r) {
+		var err error
+		if !app.isUploadAllowed(r, p) {
+			app.writeError(w, r, ErrUnauthorized)
+			return
+		}
+		if r, err = app.requestWithUpload(r); err != nil {
+			app.writeError(w, r, err)
+			return
+		}
+	}
 	blob, err := checkBlob(app.Do(r, p))
 	if err == ErrInvalid || err == ErrSignatureMismatch {
 		if path2, e := url.QueryUnescape(path); e == nil {
 			path = path2
-			p = imagorpath.Parse(path)
+			p = app.Presets.Parse(path)
 			blob, err = checkBlob(app.Do(r, p))
 		}
 	}
 	if err != nil {
-		if errors.Is(err, context.Canceled) {
-			w.WriteHeader(499)
-			return
-		}
-		e := WrapError(err)
-		if app.DisableErrorBody {
-			w.WriteHeader(e.Code)
-			return
-		}
-		w.WriteHeader(e.Code)
-		writeJSON(w, r, e)
+		app.writeError(w, r, err)
 		return
 	}
 	if isBlobEmpty(blob) {
@@ -199,6 +245,10 @@ func (app *Imagor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("Imagor-Auto-Format") != "" {
 		w.Header().Add("Vary", "Accept")
 	}
+	if app.ClientHints {
+		w.Header().Set("Accept-CH", clientHintsHeaders)
+		w.Header().Add("Vary", clientHintsHeaders)
+	}
 	if r.Header.Get("Imagor-Raw") != "" {
 		w.Header().Set("Content-Security-Policy", "script-src 'none'")
 	}
@@ -206,11 +256,212 @@ func (app *Imagor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
-	reader, size, _ := blob.NewReader()
-	writeBody(w, r, reader, size)
+	n, ok := writeRange(w, r, blob)
+	if !ok {
+		reader, size, _ := blob.NewReader()
+		n = writeBody(w, r, reader, size)
+	}
+	if app.Metrics != nil {
+		app.Metrics.ObserveBytesOut(n)
+	}
 	return
 }
 
+func (app *Imagor) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) {
+		w.WriteHeader(499)
+		return
+	}
+	e := WrapError(err)
+	if app.DisableErrorBody {
+		w.WriteHeader(e.Code)
+		return
+	}
+	w.WriteHeader(e.Code)
+	writeJSON(w, r, e)
+}
+
+// isUploadAllowed upload request requires URL signature regardless of unsafe option,
+// or admin secret bearer token for unsafe URL
+func (app *Imagor) isUploadAllowed(r *http.Request, p imagorpath.Params) bool {
+	return !p.Unsafe || app.isAdmin(r)
+}
+
+// requestWithUpload reads request body as the source Blob for upload request
+func (app *Imagor) requestWithUpload(r *http.Request) (*http.Request, error) {
+	var maxSize = app.UploadMaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultUploadMaxSize
+	}
+	if r.ContentLength > maxSize {
+		return r, ErrMaxSizeExceeded
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		return r, err
+	}
+	if int64(len(buf)) > maxSize {
+		return r, ErrMaxSizeExceeded
+	}
+	ctx := withContext(r.Context())
+	mustContextRef(ctx).Blob = NewBlobFromBytes(buf)
+	return r.WithContext(ctx), nil
+}
+
+// servePurge serves DELETE /purge/<image> admin endpoint
+func (app *Imagor) servePurge(w http.ResponseWriter, r *http.Request) {
+	if !app.isAdmin(r) {
+		app.writeError(w, r, ErrUnauthorized)
+		return
+	}
+	image := strings.TrimPrefix(r.URL.EscapedPath(), "/purge/")
+	if image == r.URL.EscapedPath() || image == "" {
+		app.writeError(w, r, ErrInvalid)
+		return
+	}
+	if u, err := url.QueryUnescape(image); err == nil {
+		image = u
+	}
+	if err := app.Purge(r.Context(), image); err != nil {
+		app.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveWarm serves POST /warm/<image>?preset=<name> admin endpoint
+func (app *Imagor) serveWarm(w http.ResponseWriter, r *http.Request) {
+	if !app.isAdmin(r) {
+		app.writeError(w, r, ErrUnauthorized)
+		return
+	}
+	image := strings.TrimPrefix(r.URL.EscapedPath(), "/warm/")
+	if u, err := url.QueryUnescape(image); err == nil {
+		image = u
+	}
+	var presets []string
+	for _, v := range r.URL.Query()["preset"] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				presets = append(presets, name)
+			}
+		}
+	}
+	results, err := app.Warm(r.Context(), image, presets...)
+	if err != nil {
+		app.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, r, struct {
+		Image   string       `json:"image"`
+		Results []WarmResult `json:"results"`
+	}{image, results})
+}
+
+// isAdmin checks request bearer token against admin secret
+func (app *Imagor) isAdmin(r *http.Request) bool {
+	if app.AdminSecret == "" {
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(app.AdminSecret)) == 1
+}
+
+// Purge deletes image from Storages, and all results derived from image from Result Storages.
+// Result Storages purge requires ResultStoragePathStyle being imagorpath.ResultStoragePrefixHasher
+// and Result Storages implementing PrefixDeleter
+func (app *Imagor) Purge(ctx context.Context, image string) error {
+	var storageKey = image
+	if app.StoragePathStyle != nil {
+		storageKey = app.StoragePathStyle.Hash(image)
+	}
+	// validate capabilities before deleting anything, avoiding partial purge
+	var hasher imagorpath.ResultStoragePrefixHasher
+	var deleters []PrefixDeleter
+	if len(app.ResultStorages) > 0 {
+		var ok bool
+		if hasher, ok = app.ResultStoragePathStyle.(imagorpath.ResultStoragePrefixHasher); !ok {
+			return ErrNotImplemented
+		}
+		for _, storage := range app.ResultStorages {
+			deleter, ok := storage.(PrefixDeleter)
+			if !ok {
+				return ErrNotImplemented
+			}
+			deleters = append(deleters, deleter)
+		}
+	}
+	for _, storage := range app.Storages {
+		if err := storage.Delete(ctx, storageKey); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if app.Debug {
+			app.Logger.Debug("purged", zap.String("key", storageKey))
+		}
+	}
+	if len(deleters) == 0 {
+		return nil
+	}
+	// prefix may also match results of other images with the same name but different extension,
+	// which is harmless for result storage that is regenerated on demand
+	var prefix = hasher.HashResultPrefix(image)
+	for _, deleter := range deleters {
+		if err := deleter.DeletePrefix(ctx, prefix); err != nil {
+			return err
+		}
+		if app.Debug {
+			app.Logger.Debug("purged", zap.String("prefix", prefix))
+		}
+	}
+	return nil
+}
+
+// WarmResult result of warming up a preset
+type WarmResult struct {
+	Preset string `json:"preset"`
+	Path   string `json:"path,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Warm generates derivatives of image by named presets ahead of time,
+// going through the normal pipeline and saved to Result Storages.
+// All presets are warmed up if none specified
+func (app *Imagor) Warm(ctx context.Context, image string, presets ...string) ([]WarmResult, error) {
+	if image == "" {
+		return nil, ErrInvalid
+	}
+	if len(presets) == 0 {
+		for name := range app.Presets {
+			presets = append(presets, name)
+		}
+		sort.Strings(presets)
+	}
+	var results = make([]WarmResult, 0, len(presets))
+	for _, name := range presets {
+		result := WarmResult{Preset: name, Status: http.StatusOK}
+		if _, ok := app.Presets[name]; !ok {
+			e := NewError(fmt.Sprintf("preset not found: %s", name), http.StatusNotFound)
+			result.Status, result.Error = e.Code, e.Message
+			results = append(results, result)
+			continue
+		}
+		// expanded the same as preset reference of URL, without parsing the URL hash
+		p := app.Presets.Apply(imagorpath.Params{}, "unsafe/p/"+name+"/"+image)
+		p.Unsafe = false
+		result.Path = imagorpath.GeneratePath(p)
+		if _, err := checkBlob(app.Serve(withWarm(ctx), p)); err != nil {
+			e := WrapError(err)
+			result.Status, result.Error = e.Code, e.Message
+			app.Logger.Warn("warm", zap.String("preset", name), zap.String("image", image), zap.Error(err))
+		} else if app.Debug {
+			app.Logger.Debug("warmed", zap.String("preset", name), zap.String("path", result.Path))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 // Serve serves imagor by context and params
 func (app *Imagor) Serve(ctx context.Context, p imagorpath.Params) (*Blob, error) {
 	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "", nil)
@@ -237,14 +488,50 @@ func (app *Imagor) ServeBlob(
 // Do executes imagor operations
 func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err error) {
 	var ctx = withContext(r.Context())
+	if app.Tracer != nil {
+		var span Span
+		ctx = withTracer(app.Tracer.Extract(ctx, r.Header), app.Tracer)
+		ctx, span = app.Tracer.Start(ctx, "imagor.Do")
+		defer func() {
+			span.End(err)
+		}()
+		r = r.WithContext(ctx)
+	}
 	var cancel func()
 	if app.RequestTimeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, app.RequestTimeout)
 		contextDefer(ctx, cancel)
 		r = r.WithContext(ctx)
 	}
+	if isUpload(r) && !app.isUploadAllowed(r, p) {
+		err = ErrUnauthorized
+		return
+	}
 	if !(app.Unsafe && p.Unsafe) && app.Signer != nil && p.Path != "" {
-		if hash := app.Signer.Sign(p.Path); hash != p.Hash {
+		if p.KeyID != "" {
+			if app.KeyVerifier == nil || !app.KeyVerifier.VerifyKey(p.KeyID, p.Path, p.Hash) {
+				err = ErrSignatureMismatch
+				if app.Debug {
+					app.Logger.Debug("sign-mismatch", zap.Any("params", p))
+				}
+				return
+			}
+			if app.Debug {
+				app.Logger.Debug("sign-match", zap.String("path", p.Path), zap.String("key_id", p.KeyID))
+			}
+		} else if verifier, ok := app.Signer.(imagorpath.Verifier); ok {
+			key, ok := verifier.Verify(p.Path, p.Hash)
+			if !ok {
+				err = ErrSignatureMismatch
+				if app.Debug {
+					app.Logger.Debug("sign-mismatch", zap.Any("params", p))
+				}
+				return
+			}
+			if app.Debug {
+				app.Logger.Debug("sign-match", zap.String("path", p.Path), zap.Int("key", key))
+			}
+		} else if hash := app.Signer.Sign(p.Path); hash != p.Hash {
 			err = ErrSignatureMismatch
 			if app.Debug {
 				app.Logger.Debug("sign-mismatch", zap.Any("params", p), zap.String("expected", hash))
@@ -252,6 +539,13 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 			return
 		}
 	}
+	if name, ok := app.Presets.Unresolved(p); ok {
+		err = ErrInvalid
+		if app.Debug {
+			app.Logger.Debug("preset-not-found", zap.String("preset", name), zap.String("path", p.Path))
+		}
+		return
+	}
 	var isPathChanged bool
 	if app.BaseParams != "" {
 		p = imagorpath.Apply(p, app.BaseParams)
@@ -288,10 +582,17 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 			p.Filters = append(p.Filters, f)
 		}
 	}
-	// auto WebP / AVIF
-	if !hasFormat && (app.AutoWebP || app.AutoAVIF) {
+	// auto WebP / AVIF / JPEG XL
+	if !hasFormat && (app.AutoWebP || app.AutoAVIF || app.AutoJXL) {
 		accept := r.Header.Get("Accept")
-		if app.AutoAVIF && strings.Contains(accept, "image/avif") {
+		if app.AutoJXL && strings.Contains(accept, "image/jxl") {
+			p.Filters = append(p.Filters, imagorpath.Filter{
+				Name: "format",
+				Args: "jxl",
+			})
+			r.Header.Set("Imagor-Auto-Format", "jxl") // response Vary: Accept header
+			isPathChanged = true
+		} else if app.AutoAVIF && strings.Contains(accept, "image/avif") {
 			p.Filters = append(p.Filters, imagorpath.Filter{
 				Name: "format",
 				Args: "avif",
@@ -307,6 +608,10 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 			isPathChanged = true
 		}
 	}
+	// client hints DPR, Width, Viewport-Width
+	if app.ClientHints && app.applyClientHints(r, &p) {
+		isPathChanged = true
+	}
 	if isPathChanged || p.Path == "" {
 		p.Path = imagorpath.GeneratePath(p)
 	}
@@ -326,19 +631,57 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 			resultKey = p.Path
 		}
 	}
+	// upload request takes request body as source Blob,
+	// which should neither be suppressed nor loaded from result storage
+	var uploadBlob *Blob
+	var suppressKey = resultKey
+	if isUpload(r) {
+		if uploadBlob = mustContextRef(ctx).Blob; uploadBlob != nil {
+			suppressKey = ""
+		}
+	}
 	load := func(image string) (*Blob, error) {
 		blob, _, err := app.loadStorage(r, image)
 		return blob, err
 	}
-	return app.suppress(ctx, resultKey, func(ctx context.Context, cb func(*Blob, error)) (*Blob, error) {
-		if resultKey != "" && !isRaw {
-			if blob := app.loadResult(r, resultKey, p.Image); blob != nil {
+	// process limit applies to each caller on result storage miss,
+	// hence checked before suppress instead of shared among callers
+	var isResultChecked bool
+	if hasProcessLimit(ctx) {
+		// raw skips result storage but still loads the source
+		if resultKey != "" && !isRaw && uploadBlob == nil {
+			isResultChecked = true
+			blob := app.loadResult(r, resultKey, p.Image)
+			if app.Metrics != nil && len(app.ResultStorages) > 0 {
+				app.Metrics.ObserveResultStorage(blob != nil)
+			}
+			if blob != nil {
+				return blob, nil
+			}
+		}
+		if err = processLimit(ctx); err != nil {
+			if app.Debug {
+				app.Logger.Debug("process-limit", zap.Error(err))
+			}
+			return nil, err
+		}
+	}
+	return app.suppress(ctx, suppressKey, func(ctx context.Context, cb func(*Blob, error)) (*Blob, error) {
+		if resultKey != "" && !isRaw && uploadBlob == nil && !isResultChecked {
+			blob := app.loadResult(r, resultKey, p.Image)
+			if app.Metrics != nil && len(app.ResultStorages) > 0 {
+				app.Metrics.ObserveResultStorage(blob != nil)
+			}
+			if blob != nil {
 				return blob, nil
 			}
 		}
 		if app.queueSema != nil && !isRaw {
 			if !app.queueSema.TryAcquire(1) {
 				err = ErrTooManyRequests
+				if app.Metrics != nil {
+					app.Metrics.ObserveRejected()
+				}
 				if app.Debug {
 					app.Logger.Debug("queue-acquire", zap.Error(err))
 				}
@@ -347,7 +690,16 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 			defer app.queueSema.Release(1)
 		}
 		if app.sema != nil && !isRaw {
-			if err = app.sema.Acquire(ctx, 1); err != nil {
+			_, span := StartSpan(ctx, "imagor.acquire")
+			if app.Metrics != nil {
+				app.Metrics.AddQueueDepth(1)
+			}
+			err = app.sema.Acquire(ctx, 1)
+			if app.Metrics != nil {
+				app.Metrics.AddQueueDepth(-1)
+			}
+			span.End(err)
+			if err != nil {
 				if app.Debug {
 					app.Logger.Debug("acquire", zap.Error(err))
 				}
@@ -355,20 +707,25 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 			}
 			defer app.sema.Release(1)
 		}
-		var shouldSave bool
-		if blob, shouldSave, err = app.loadStorage(r, p.Image); err != nil {
+		var shouldSave, shouldSaveUpload bool
+		if uploadBlob != nil {
+			blob = uploadBlob
+			// upload is only persisted after processed successfully,
+			// such that existing image is not overwritten by invalid upload
+			shouldSaveUpload = p.Image != "" && len(app.Storages) > 0
+		} else if blob, shouldSave, err = app.loadStorage(r, p.Image); err != nil {
 			if app.Debug {
 				app.Logger.Debug("load", zap.Any("params", p), zap.Error(err))
 			}
 			return blob, err
 		}
+		var storageKey = p.Image
+		if app.StoragePathStyle != nil {
+			storageKey = app.StoragePathStyle.Hash(p.Image)
+		}
 		var doneSave chan struct{}
 		if shouldSave {
 			doneSave = make(chan struct{})
-			var storageKey = p.Image
-			if app.StoragePathStyle != nil {
-				storageKey = app.StoragePathStyle.Hash(p.Image)
-			}
 			go func(blob *Blob) {
 				app.save(ctx, app.Storages, storageKey, blob)
 				close(doneSave)
@@ -384,8 +741,13 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 				contextDefer(ctx, cancel)
 			}
 			var forwardP = p
+			var source = blob
+			var start = time.Now()
 			for _, processor := range app.Processors {
-				b, e := checkBlob(processor.Process(ctx, blob, forwardP, load))
+				spanCtx, span := StartSpan(ctx, "imagor.process")
+				span.SetAttribute("imagor.processor", getType(processor))
+				b, e := checkBlob(processor.Process(spanCtx, blob, forwardP, load))
+				span.End(e)
 				if !isBlobEmpty(b) {
 					blob = b // forward Blob to next processor if exists
 				}
@@ -412,12 +774,27 @@ func (app *Imagor) Do(r *http.Request, p imagorpath.Params) (blob *Blob, err err
 					break
 				}
 			}
+			if app.Metrics != nil && len(app.Processors) > 0 {
+				var format string
+				if err == nil && !isBlobEmpty(blob) {
+					format = strings.TrimPrefix(getExtension(blob.BlobType()), ".")
+				}
+				app.Metrics.ObserveProcess(format, time.Since(start), err)
+				app.Metrics.ObserveBytesIn(source.Size())
+			}
 		}
 		if shouldSave {
 			// make sure storage saved before response and result storage
 			<-doneSave
 		}
-		cb(blob, err)
+		if shouldSaveUpload && err == nil {
+			app.save(detachContext(ctx), app.Storages, storageKey, uploadBlob)
+		}
+		if !isWarm(ctx) {
+			// respond before result storage save,
+			// unless warming up which should wait for save to complete
+			cb(blob, err)
+		}
 		ctx = detachContext(ctx)
 		if err == nil && !isBlobEmpty(blob) && resultKey != "" && !isRaw &&
 			len(app.ResultStorages) > 0 {
@@ -442,43 +819,72 @@ func (app *Imagor) requestWithLoadContext(r *http.Request) *http.Request {
 }
 
 func (app *Imagor) loadResult(r *http.Request, resultKey, imageKey string) *Blob {
-	r = app.requestWithLoadContext(r)
-	ctx := r.Context()
-	blob, origin, err := fromStorages(r, app.ResultStorages, resultKey)
+	ctx, span := StartSpan(r.Context(), "imagor.load_result")
+	span.SetAttribute("imagor.result_key", resultKey)
+	defer span.End(nil)
+	r = app.requestWithLoadContext(r.WithContext(ctx))
+	ctx = r.Context()
+	blob, idx, err := fromStoragesIndex(r, app.ResultStorages, resultKey)
 	if err == nil && !isBlobEmpty(blob) {
-		if app.ModifiedTimeCheck && origin != nil && blob.Stat != nil {
+		if app.ModifiedTimeCheck && idx > -1 && blob.Stat != nil {
 			if sourceStat, err2 := app.storageStat(ctx, imageKey); sourceStat != nil && err2 == nil {
 				if !blob.Stat.ModifiedTime.Before(sourceStat.ModifiedTime) {
+					app.fillResult(ctx, idx, resultKey, blob)
 					return blob
 				}
 			}
 		} else {
+			app.fillResult(ctx, idx, resultKey, blob)
 			return blob
 		}
 	}
 	return nil
 }
 
+// fillResult saves result Blob to the result storages preceding its origin,
+// such that faster tiers e.g. in-memory cache get filled on read-through
+func (app *Imagor) fillResult(ctx context.Context, idx int, resultKey string, blob *Blob) {
+	if idx < 1 {
+		return
+	}
+	go app.save(detachContext(ctx), app.ResultStorages[:idx], resultKey, blob)
+}
+
 func fromStorages(
 	r *http.Request, storages []Storage, key string,
 ) (blob *Blob, origin Storage, err error) {
-	for _, storage := range storages {
+	blob, idx, err := fromStoragesIndex(r, storages, key)
+	if idx > -1 {
+		origin = storages[idx]
+	}
+	return
+}
+
+func fromStoragesIndex(
+	r *http.Request, storages []Storage, key string,
+) (blob *Blob, idx int, err error) {
+	for i, storage := range storages {
 		b, e := checkBlob(storage.Get(r, key))
 		if !isBlobEmpty(b) {
 			blob = b
 			if e == nil {
 				err = nil
-				origin = storage
+				idx = i
 				return
 			}
 		}
 		err = e
 	}
-	return
+	return blob, -1, err
 }
 
 func (app *Imagor) loadStorage(r *http.Request, key string) (blob *Blob, shouldSave bool, err error) {
-	r = app.requestWithLoadContext(r)
+	ctx, span := StartSpan(r.Context(), "imagor.load")
+	span.SetAttribute("imagor.image", key)
+	defer func() {
+		span.End(err)
+	}()
+	r = app.requestWithLoadContext(r.WithContext(ctx))
 	var origin Storage
 	blob, origin, err = app.fromStoragesAndLoaders(r, app.Storages, app.Loaders, key)
 	if !isBlobEmpty(blob) && origin == nil &&
@@ -511,7 +917,11 @@ func (app *Imagor) fromStoragesAndLoaders(
 		}
 	}
 	for _, loader := range loaders {
+		start := time.Now()
 		b, e := checkBlob(loader.Get(r, image))
+		if app.Metrics != nil {
+			app.Metrics.ObserveLoad(getType(loader), time.Since(start), e)
+		}
 		if !isBlobEmpty(b) {
 			blob = b
 			if e == nil {
@@ -540,6 +950,9 @@ func (app *Imagor) save(ctx context.Context, storages []Storage, key string, blo
 	if key == "" {
 		return
 	}
+	ctx, span := StartSpan(ctx, "imagor.save")
+	span.SetAttribute("imagor.key", key)
+	defer span.End(nil)
 	if app.SaveTimeout > 0 {
 		var cancel func()
 		ctx, cancel = context.WithTimeout(ctx, app.SaveTimeout)
@@ -609,7 +1022,9 @@ func (app *Imagor) suppress(
 		chanCb <- singleflight.Result{Val: blob, Err: err}
 	}
 	isCanceled := false
+	isExecuted := false
 	ch := app.g.DoChan(key, func() (v interface{}, err error) {
+		isExecuted = true
 		v, err = fn(context.WithValue(ctx, suppressKey{key}, true), cb)
 		if errors.Is(err, context.Canceled) {
 			app.g.Forget(key)
@@ -623,6 +1038,9 @@ func (app *Imagor) suppress(
 			// resolve canceled
 			return app.suppress(ctx, key, fn)
 		}
+		if !isExecuted && app.Metrics != nil {
+			app.Metrics.ObserveSuppressed()
+		}
 		if res.Val != nil {
 			return res.Val.(*Blob), res.Err
 		}
@@ -772,7 +1190,7 @@ func writeJSONIndent(w http.ResponseWriter, r *http.Request, v interface{}) {
 	return
 }
 
-func writeBody(w http.ResponseWriter, r *http.Request, reader io.ReadCloser, size int64) {
+func writeBody(w http.ResponseWriter, r *http.Request, reader io.ReadCloser, size int64) (n int64) {
 	defer func() {
 		_ = reader.Close()
 	}()
@@ -780,16 +1198,18 @@ func writeBody(w http.ResponseWriter, r *http.Request, reader io.ReadCloser, siz
 		// total size known, use io.Copy
 		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
 		if r.Method != http.MethodHead {
-			_, _ = io.Copy(w, reader)
+			n, _ = io.Copy(w, reader)
 		}
 	} else {
 		// total size unknown, read all
 		buf, _ := io.ReadAll(reader)
 		w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
 		if r.Method != http.MethodHead {
-			_, _ = w.Write(buf)
+			written, _ := w.Write(buf)
+			n = int64(written)
 		}
 	}
+	return
 }
 
 func getContentDisposition(p imagorpath.Params, blob *Blob) string {
@@ -810,6 +1230,96 @@ func getContentDisposition(p imagorpath.Params, blob *Blob) string {
 	return "inline"
 }
 
+const clientHintsHeaders = "Sec-CH-DPR, Sec-CH-Width, Sec-CH-Viewport-Width"
+
+// clientHintsDPRs supported DPR of client hints
+var clientHintsDPRs = []float64{1, 1.5, 2, 3}
+
+// clientHintsWidthStep width step of client hints width and viewport width
+const clientHintsWidthStep = 100
+
+// DefaultClientHintsMaxSize default maximum image width and height scaled by client hints
+const DefaultClientHintsMaxSize = 3840
+
+// applyClientHints scales params dimensions by client hints request headers,
+// clamped to the client hints maximum width and height.
+// Returns true if params dimensions are changed
+func (app *Imagor) applyClientHints(r *http.Request, p *imagorpath.Params) bool {
+	var dpr float64 = 1
+	if v, err := strconv.ParseFloat(r.Header.Get("Sec-CH-DPR"), 64); err == nil && v > 0 {
+		dpr = quantizeDPR(v)
+	}
+	chWidth, _ := strconv.Atoi(r.Header.Get("Sec-CH-Width"))
+	viewportWidth, _ := strconv.Atoi(r.Header.Get("Sec-CH-Viewport-Width"))
+	width, height := p.Width, p.Height
+	if width == 0 && height == 0 {
+		if chWidth > 0 {
+			// Sec-CH-Width is already in physical pixels
+			width = quantizeWidth(chWidth)
+		} else if viewportWidth > 0 {
+			width = quantizeWidth(int(math.Round(float64(viewportWidth) * dpr)))
+		}
+	} else if dpr != 1 {
+		width = int(math.Round(float64(width) * dpr))
+		height = int(math.Round(float64(height) * dpr))
+	}
+	if width == p.Width && height == p.Height && viewportWidth <= 0 {
+		return false
+	}
+	if viewportWidth > 0 {
+		if maxWidth := quantizeWidth(int(math.Round(float64(viewportWidth) * dpr))); absInt(width) > maxWidth {
+			width, height = clampDimension(width, height, maxWidth)
+		}
+	}
+	if app.ClientHintsMaxWidth > 0 && absInt(width) > app.ClientHintsMaxWidth {
+		width, height = clampDimension(width, height, app.ClientHintsMaxWidth)
+	}
+	if app.ClientHintsMaxHeight > 0 && absInt(height) > app.ClientHintsMaxHeight {
+		height, width = clampDimension(height, width, app.ClientHintsMaxHeight)
+	}
+	if width == p.Width && height == p.Height {
+		return false
+	}
+	p.Width, p.Height = width, height
+	return true
+}
+
+// quantizeDPR rounds DPR up to the nearest of clientHintsDPRs, capped by the largest,
+// such that client hints result in bounded variants of result
+func quantizeDPR(dpr float64) float64 {
+	for _, v := range clientHintsDPRs {
+		if dpr <= v {
+			return v
+		}
+	}
+	return clientHintsDPRs[len(clientHintsDPRs)-1]
+}
+
+// quantizeWidth rounds width up to multiple of clientHintsWidthStep
+func quantizeWidth(width int) int {
+	return (width + clientHintsWidthStep - 1) / clientHintsWidthStep * clientHintsWidthStep
+}
+
+// clampDimension clamps size to max, scaling the other dimension proportionally
+func clampDimension(size, other, max int) (int, int) {
+	other = int(math.Round(float64(other) * float64(max) / float64(absInt(size))))
+	if size < 0 {
+		return -max, other
+	}
+	return max, other
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func isUpload(r *http.Request) bool {
+	return r.Method == http.MethodPost || r.Method == http.MethodPut
+}
+
 func getType(v interface{}) string {
 	t := reflect.TypeOf(v)
 	if t.Kind() == reflect.Ptr {
@@ -3,6 +3,7 @@ package imagor
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
@@ -13,6 +14,8 @@ import (
 	"go.uber.org/zap"
 	"io"
 	"math/rand"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -265,6 +268,66 @@ func TestWithCustomSigner(t *testing.T) {
 	assert.Equal(t, w.Body.String(), jsonStr(ErrSignatureMismatch))
 }
 
+func TestWithMultiSigner(t *testing.T) {
+	app := New(
+		WithDebug(true),
+		WithLogger(zap.NewExample()),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			return NewBlobFromBytes([]byte("foo")), nil
+		})),
+		WithSigner(imagorpath.NewMultiSigner(
+			imagorpath.NewDefaultSigner("5678"),
+			imagorpath.LegacySigner{Signer: imagorpath.NewDefaultSigner("1234")},
+			imagorpath.LegacySigner{Signer: imagorpath.NewDefaultSigner("abcd"), RetireAt: time.Now().Add(-time.Hour)},
+		)))
+
+	for _, secret := range []string{"5678", "1234"} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "https://example.com/"+imagorpath.NewDefaultSigner(secret).Sign("foo.jpg")+"/foo.jpg", nil))
+		assert.Equal(t, 200, w.Code, secret)
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "https://example.com/"+imagorpath.NewDefaultSigner("abcd").Sign("foo.jpg")+"/foo.jpg", nil))
+	assert.Equal(t, 403, w.Code, "retired")
+	assert.Equal(t, w.Body.String(), jsonStr(ErrSignatureMismatch))
+}
+
+func TestWithKeyVerifier(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	other := ed25519.NewKeyFromSeed(append(make([]byte, ed25519.SeedSize-1), 1))
+	app := New(
+		WithDebug(true),
+		WithLogger(zap.NewExample()),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			return NewBlobFromBytes([]byte("foo")), nil
+		})),
+		WithSigner(imagorpath.NewDefaultSigner("1234")),
+		WithKeyVerifier(imagorpath.NewEd25519Verifier(map[string]ed25519.PublicKey{
+			"partner1": key.Public().(ed25519.PublicKey),
+		})))
+
+	for path, code := range map[string]int{
+		imagorpath.NewEd25519Signer("partner1", key).Sign("foo.jpg"):   200,
+		imagorpath.NewDefaultSigner("1234").Sign("foo.jpg"):            200,
+		imagorpath.NewEd25519Signer("partner1", other).Sign("foo.jpg"): 403,
+		imagorpath.NewEd25519Signer("partner2", key).Sign("foo.jpg"):   403,
+		imagorpath.NewEd25519Signer("partner1", key).Sign("bar.jpg"):   403,
+	} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "https://example.com/"+path+"/foo.jpg", nil))
+		assert.Equal(t, code, w.Code, path)
+	}
+
+	w := httptest.NewRecorder()
+	New(WithSigner(imagorpath.NewDefaultSigner("1234"))).ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "https://example.com/"+imagorpath.NewEd25519Signer("partner1", key).Sign("foo.jpg")+"/foo.jpg", nil))
+	assert.Equal(t, 403, w.Code, "no key verifier")
+}
+
 func TestWithRetryQueryUnescape(t *testing.T) {
 	opts := WithOptions(
 		WithDebug(true),
@@ -562,6 +625,412 @@ func TestParams(t *testing.T) {
 
 var clock time.Time
 
+func TestWithUpload(t *testing.T) {
+	store := newMapStore()
+	resultStore := newMapStore()
+	app := New(
+		WithDebug(true), WithLogger(zap.NewExample()),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			return NewBlobFromBytes([]byte("loaded")), nil
+		})),
+		WithStorages(store),
+		WithResultStorages(resultStore),
+		WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+			buf, _ := blob.ReadAll()
+			if string(buf) == "fail" {
+				return nil, ErrUnsupportedFormat
+			}
+			return NewBlobFromBytes([]byte(string(buf) + ":" + p.Path)), nil
+		})),
+		WithUploadEnabled(true),
+		WithUploadMaxSize(5),
+		WithUnsafe(true),
+		WithAdminSecret("s3cret"),
+	)
+	assert.True(t, app.UploadEnabled)
+	assert.Equal(t, int64(5), app.UploadMaxSize)
+	upload := func(method, path, body string) *http.Request {
+		r := httptest.NewRequest(method, "https://example.com"+path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer s3cret")
+		return r
+	}
+
+	t.Run("upload processes request body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, upload(http.MethodPost, "/unsafe/fit-in/100x100/foo", "bar"))
+		time.Sleep(time.Millisecond * 10) // make sure storage reached
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "bar:fit-in/100x100/foo", w.Body.String())
+		buf, err := store.Map["foo"].ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "bar", string(buf))
+		assert.Equal(t, 1, resultStore.SaveCnt["fit-in/100x100/foo"])
+		assert.Empty(t, resultStore.LoadCnt["fit-in/100x100/foo"])
+	})
+
+	t.Run("upload skips result storage lookup", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, upload(http.MethodPut, "/unsafe/fit-in/100x100/foo", "boo"))
+		time.Sleep(time.Millisecond * 10) // make sure storage reached
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "boo:fit-in/100x100/foo", w.Body.String())
+		assert.Equal(t, 2, store.SaveCnt["foo"])
+		assert.Equal(t, 2, resultStore.SaveCnt["fit-in/100x100/foo"])
+		assert.Empty(t, resultStore.LoadCnt["fit-in/100x100/foo"])
+
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "https://example.com/unsafe/fit-in/100x100/foo", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "boo:fit-in/100x100/foo", w.Body.String())
+		assert.Equal(t, 1, resultStore.LoadCnt["fit-in/100x100/foo"])
+	})
+
+	t.Run("upload exceeds max size", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, upload(http.MethodPost, "/unsafe/bar", "abcdefg"))
+		assert.Equal(t, 400, w.Code)
+		assert.Equal(t, jsonStr(ErrMaxSizeExceeded), w.Body.String())
+		assert.Empty(t, store.SaveCnt["bar"])
+	})
+
+	t.Run("upload default max size", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "https://example.com/unsafe/bar", strings.NewReader("abc"))
+		r.Header.Set("Authorization", "Bearer s3cret")
+		r.ContentLength = DefaultUploadMaxSize + 1
+		New(WithUnsafe(true), WithUploadEnabled(true), WithAdminSecret("s3cret")).ServeHTTP(w, r)
+		assert.Equal(t, 400, w.Code)
+		assert.Equal(t, jsonStr(ErrMaxSizeExceeded), w.Body.String())
+	})
+
+	t.Run("upload processing failure not persisted", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, upload(http.MethodPut, "/unsafe/fit-in/100x100/foo", "fail"))
+		time.Sleep(time.Millisecond * 10) // make sure storage reached
+		assert.Equal(t, 406, w.Code)
+		assert.Equal(t, 2, store.SaveCnt["foo"])
+		buf, err := store.Map["foo"].ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "boo", string(buf), "existing image kept")
+	})
+
+	t.Run("unsafe upload requires admin secret", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "https://example.com/unsafe/fit-in/100x100/foo", strings.NewReader("bad")))
+		assert.Equal(t, 401, w.Code)
+		assert.Equal(t, jsonStr(ErrUnauthorized), w.Body.String())
+		assert.Equal(t, 2, store.SaveCnt["foo"])
+
+		w = httptest.NewRecorder()
+		r := upload(http.MethodPost, "/unsafe/fit-in/100x100/foo", "bad")
+		r.Header.Set("Authorization", "Bearer wrong")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 401, w.Code)
+
+		w = httptest.NewRecorder()
+		New(WithUnsafe(true), WithUploadEnabled(true)).ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "https://example.com/unsafe/foo", strings.NewReader("bad")))
+		assert.Equal(t, 401, w.Code, "no admin secret")
+	})
+
+	t.Run("signed upload under unsafe", func(t *testing.T) {
+		signer := imagorpath.NewDefaultSigner("1234")
+		w := httptest.NewRecorder()
+		New(WithUnsafe(true), WithUploadEnabled(true), WithSigner(signer),
+			WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+				return blob, nil
+			})),
+		).ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "https://example.com/"+signer.Sign("foo.jpg")+"/foo.jpg", strings.NewReader("abc")))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "abc", w.Body.String())
+	})
+
+	t.Run("upload not enabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		New(WithUnsafe(true)).ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "https://example.com/unsafe/bar", strings.NewReader("abc")))
+		assert.Equal(t, 405, w.Code)
+	})
+
+	t.Run("upload requires signature", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		New(WithUploadEnabled(true), WithSigner(imagorpath.NewDefaultSigner("1234"))).ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "https://example.com/_-19cQt1szHeUV0WyWFntvTIm/foo.jpg", strings.NewReader("abc")))
+		assert.Equal(t, 403, w.Code)
+		assert.Equal(t, jsonStr(ErrSignatureMismatch), w.Body.String())
+	})
+}
+
+func TestPurge(t *testing.T) {
+	store := newMapStore()
+	resultStore := newMapStore()
+	newApp := func(options ...Option) *Imagor {
+		return New(append([]Option{
+			WithDebug(true), WithLogger(zap.NewExample()),
+			WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+				return NewBlobFromBytes([]byte(image)), nil
+			})),
+			WithStorages(store),
+			WithResultStorages(resultStore),
+			WithUnsafe(true),
+		}, options...)...)
+	}
+	app := newApp(
+		WithResultStoragePathStyle(imagorpath.SuffixResultStorageHasher),
+		WithAdminSecret("s3cret"))
+	for _, path := range []string{"/unsafe/foo.jpg", "/unsafe/fit-in/10x10/foo.jpg", "/unsafe/foo.png", "/unsafe/bar.jpg"} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil))
+		assert.Equal(t, 200, w.Code)
+	}
+	time.Sleep(time.Millisecond * 10) // make sure storage reached
+	assert.Len(t, store.Map, 3)
+	assert.Len(t, resultStore.Map, 4)
+
+	t.Run("unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "https://example.com/purge/foo.jpg", nil))
+		assert.Equal(t, 401, w.Code)
+		assert.Equal(t, jsonStr(ErrUnauthorized), w.Body.String())
+
+		w = httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "https://example.com/purge/foo.jpg", nil)
+		r.Header.Set("Authorization", "Bearer foo")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 401, w.Code)
+		assert.Len(t, store.Map, 3)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "https://example.com/foo.jpg", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 400, w.Code)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "https://example.com/purge/foo.jpg", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 204, w.Code)
+		assert.Equal(t, 1, store.DelCnt["foo.jpg"])
+		assert.NotContains(t, store.Map, "foo.jpg")
+		assert.Contains(t, store.Map, "bar.jpg")
+		assert.Len(t, resultStore.Map, 1)
+		for key := range resultStore.Map {
+			assert.True(t, strings.HasPrefix(key, "bar."))
+		}
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodDelete, "https://example.com/purge/foo.jpg", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 204, w.Code)
+	})
+
+	t.Run("result storage path style not supported", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "https://example.com/purge/bar.jpg", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		newApp(WithAdminSecret("s3cret")).ServeHTTP(w, r)
+		assert.Equal(t, 501, w.Code)
+		assert.Equal(t, jsonStr(ErrNotImplemented), w.Body.String())
+		assert.Contains(t, store.Map, "bar.jpg", "not purged partially")
+		assert.Len(t, resultStore.Map, 1)
+	})
+
+	t.Run("result storage prefix delete not supported", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "https://example.com/purge/bar.jpg", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		newApp(
+			WithAdminSecret("s3cret"),
+			WithResultStoragePathStyle(imagorpath.SuffixResultStorageHasher),
+			WithResultStorages(struct{ Storage }{resultStore}),
+		).ServeHTTP(w, r)
+		assert.Equal(t, 501, w.Code)
+		assert.Contains(t, store.Map, "bar.jpg", "not purged partially")
+		assert.Len(t, resultStore.Map, 1)
+	})
+
+	t.Run("admin secret not set", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "https://example.com/purge/bar.jpg", nil)
+		r.Header.Set("Authorization", "Bearer ")
+		newApp().ServeHTTP(w, r)
+		assert.Equal(t, 405, w.Code)
+		assert.Contains(t, store.Map, "foo.png")
+	})
+}
+
+func TestPresets(t *testing.T) {
+	resultStore := newMapStore()
+	signer := imagorpath.NewDefaultSigner("1234")
+	app := New(
+		WithSigner(signer),
+		WithPresets(map[string]string{
+			"card": "fit-in/300x200/filters:format(webp):quality(80)",
+		}),
+		WithResultStorages(resultStore),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			return NewBlobFromBytes([]byte(image)), nil
+		})),
+		WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+			buf, _ := blob.ReadAll()
+			return NewBlobFromBytes([]byte(string(buf) + ":" + p.Path)), nil
+		})),
+	)
+	full := "fit-in/300x200/filters:format(webp):quality(80)/foo.jpg"
+	hash := signer.Sign(full)
+
+	for _, path := range []string{
+		"/" + hash + "/preset:card/foo.jpg",
+		"/" + hash + "/p/card/foo.jpg",
+		"/" + hash + "/" + full,
+	} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil))
+		assert.Equal(t, 200, w.Code, path)
+		assert.Equal(t, "foo.jpg:"+full, w.Body.String(), path)
+	}
+	assert.Eventually(t, func() bool {
+		resultStore.l.RLock()
+		defer resultStore.l.RUnlock()
+		return resultStore.Map[full] != nil
+	}, time.Second, time.Millisecond)
+	resultStore.l.RLock()
+	assert.Len(t, resultStore.Map, 1, "hashed the same as the full form")
+	resultStore.l.RUnlock()
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/"+signer.Sign("p/card/foo.jpg")+"/p/card/foo.jpg", nil))
+	assert.Equal(t, 403, w.Code, "signed by the expanded form")
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/params/"+hash+"/p/card/foo.jpg", nil))
+	assert.Equal(t, 200, w.Code)
+	buf, _ := json.MarshalIndent(imagorpath.Parse("/params/"+hash+"/"+full), "", "  ")
+	assert.Equal(t, string(buf), w.Body.String(), "params endpoint shows expanded form")
+
+	for _, path := range []string{"p/typo/foo.jpg", "preset:typo/foo.jpg"} {
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/"+signer.Sign(path)+"/"+path, nil))
+		assert.Equal(t, 400, w.Code, "unknown preset")
+	}
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/"+signer.Sign("p%2Ftypo%2Ffoo.jpg")+"/p%2Ftypo%2Ffoo.jpg", nil))
+	assert.Equal(t, 200, w.Code, "URL encoded image path")
+}
+
+func TestWarm(t *testing.T) {
+	resultStore := newMapStore()
+	app := New(
+		WithUnsafe(true),
+		WithAdminSecret("s3cret"),
+		WithPresets(map[string]string{
+			"thumb": "fit-in/100x100",
+			"card":  "/300x200/filters:format(webp)/",
+			"hd":    "1920x1080/filters:quality(80)",
+		}),
+		WithPresets(map[string]string{"broken": "200x200"}),
+		WithResultStorages(resultStore),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			if image == "bar.jpg" {
+				return nil, ErrNotFound
+			}
+			return NewBlobFromBytes([]byte(image)), nil
+		})),
+		WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+			if p.Width == 200 && p.Height == 200 {
+				return nil, ErrUnsupportedFormat
+			}
+			buf, _ := blob.ReadAll()
+			return NewBlobFromBytes([]byte(string(buf) + ":" + p.Path)), nil
+		})),
+	)
+	assert.Len(t, app.Presets, 4)
+
+	t.Run("warm presets", func(t *testing.T) {
+		results, err := app.Warm(context.Background(), "foo.jpg", "thumb", "card", "hero")
+		require.NoError(t, err)
+		assert.Equal(t, []WarmResult{
+			{Preset: "thumb", Path: "fit-in/100x100/foo.jpg", Status: 200},
+			{Preset: "card", Path: "300x200/filters:format(webp)/foo.jpg", Status: 200},
+			{Preset: "hero", Status: 404, Error: "preset not found: hero"},
+		}, results)
+		// saved to result storage before return
+		assert.Equal(t, 1, resultStore.SaveCnt["fit-in/100x100/foo.jpg"])
+		assert.Equal(t, 1, resultStore.SaveCnt["300x200/filters:format(webp)/foo.jpg"])
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/fit-in/100x100/foo.jpg", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "foo.jpg:fit-in/100x100/foo.jpg", w.Body.String())
+		assert.Equal(t, 1, resultStore.LoadCnt["fit-in/100x100/foo.jpg"])
+	})
+
+	t.Run("warm dimensions preset", func(t *testing.T) {
+		results, err := app.Warm(context.Background(), "foo.jpg", "hd")
+		require.NoError(t, err)
+		assert.Equal(t, []WarmResult{
+			{Preset: "hd", Path: "1920x1080/filters:quality(80)/foo.jpg", Status: 200},
+		}, results)
+		assert.Equal(t, 1, resultStore.SaveCnt["1920x1080/filters:quality(80)/foo.jpg"])
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/p/hd/foo.jpg", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "foo.jpg:1920x1080/filters:quality(80)/foo.jpg", w.Body.String())
+		assert.Equal(t, 1, resultStore.LoadCnt["1920x1080/filters:quality(80)/foo.jpg"], "same result key as preset URL")
+	})
+
+	t.Run("warm all presets", func(t *testing.T) {
+		results, err := app.Warm(context.Background(), "bar.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, []WarmResult{
+			{Preset: "broken", Path: "200x200/bar.jpg", Status: 404, Error: "not found"},
+			{Preset: "card", Path: "300x200/filters:format(webp)/bar.jpg", Status: 404, Error: "not found"},
+			{Preset: "hd", Path: "1920x1080/filters:quality(80)/bar.jpg", Status: 404, Error: "not found"},
+			{Preset: "thumb", Path: "fit-in/100x100/bar.jpg", Status: 404, Error: "not found"},
+		}, results)
+		assert.Empty(t, resultStore.SaveCnt["fit-in/100x100/bar.jpg"])
+
+		_, err = app.Warm(context.Background(), "")
+		assert.Equal(t, ErrInvalid, err)
+	})
+
+	t.Run("warm endpoint", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "https://example.com/warm/baz.jpg?preset=thumb", nil))
+		assert.Equal(t, 401, w.Code)
+		assert.Equal(t, jsonStr(ErrUnauthorized), w.Body.String())
+
+		w = httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "https://example.com/warm/baz.jpg?preset=thumb,broken&preset=card", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, `{"image":"baz.jpg","results":[`+
+			`{"preset":"thumb","path":"fit-in/100x100/baz.jpg","status":200},`+
+			`{"preset":"broken","path":"200x200/baz.jpg","status":406,"error":"unsupported format"},`+
+			`{"preset":"card","path":"300x200/filters:format(webp)/baz.jpg","status":200}]}`,
+			w.Body.String())
+		assert.Equal(t, 1, resultStore.SaveCnt["fit-in/100x100/baz.jpg"])
+		assert.Empty(t, resultStore.SaveCnt["200x200/baz.jpg"])
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "https://example.com/warm/", nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		app.ServeHTTP(w, r)
+		assert.Equal(t, 400, w.Code)
+	})
+}
+
 type mapStore struct {
 	l       sync.RWMutex
 	Map     map[string]*Blob
@@ -608,6 +1077,18 @@ func (s *mapStore) Delete(ctx context.Context, image string) error {
 	return nil
 }
 
+func (s *mapStore) DeletePrefix(ctx context.Context, prefix string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	for image := range s.Map {
+		if strings.HasPrefix(image, prefix) {
+			delete(s.Map, image)
+			s.DelCnt[image] = s.DelCnt[image] + 1
+		}
+	}
+	return nil
+}
+
 func (s *mapStore) Stat(ctx context.Context, image string) (*Stat, error) {
 	s.l.RLock()
 	defer s.l.RUnlock()
@@ -1142,6 +1623,40 @@ func TestWithProcessConcurrency(t *testing.T) {
 	assert.Equal(t, 4, result[429])
 }
 
+func TestWithSharedProcessQueue(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+		if image == "block" {
+			close(started)
+			<-release
+		}
+		return NewBlobFromBytes([]byte(image)), nil
+	})
+	app := New(WithUnsafe(true), WithProcessConcurrency(1), WithLoaders(loader))
+	tenant := New(WithUnsafe(true), WithSharedProcessQueue(app), WithLoaders(loader))
+	assert.Equal(t, int64(1), tenant.ProcessConcurrency)
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/block", nil))
+		done <- w.Code
+	}()
+	<-started
+	w := httptest.NewRecorder()
+	tenant.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo", nil))
+	assert.Equal(t, 429, w.Code, "concurrency shared across instances")
+	close(release)
+	assert.Equal(t, 200, <-done)
+
+	w = httptest.NewRecorder()
+	tenant.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo", nil))
+	assert.Equal(t, 200, w.Code)
+
+	assert.Nil(t, New(WithSharedProcessQueue(New())).sema)
+}
+
 func TestWithModifiedTimeCheck(t *testing.T) {
 	store := newMapStore()
 	resultStore := newMapStore()
@@ -1191,6 +1706,40 @@ func TestWithModifiedTimeCheck(t *testing.T) {
 	assert.Equal(t, 2, resultStore.SaveCnt["foo"])
 }
 
+func TestWithResultStoragesFill(t *testing.T) {
+	fastStore := newMapStore()
+	slowStore := newMapStore()
+	app := New(
+		WithDebug(true), WithLogger(zap.NewExample()),
+		WithResultStorages(fastStore, slowStore),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			return NewBlobFromBytes([]byte(image)), nil
+		})),
+		WithUnsafe(true),
+	)
+	require.NoError(t, slowStore.Put(context.Background(), "foo", NewBlobFromBytes([]byte("bar"))))
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "https://example.com/unsafe/foo", nil))
+	time.Sleep(time.Millisecond * 10) // make sure storage reached
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "bar", w.Body.String())
+	assert.Equal(t, 1, slowStore.LoadCnt["foo"])
+	assert.Equal(t, 1, fastStore.SaveCnt["foo"])
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "https://example.com/unsafe/foo", nil))
+	time.Sleep(time.Millisecond * 10) // make sure storage reached
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "bar", w.Body.String())
+	assert.Equal(t, 1, fastStore.LoadCnt["foo"])
+	assert.Equal(t, 1, fastStore.SaveCnt["foo"])
+	assert.Equal(t, 1, slowStore.LoadCnt["foo"])
+	assert.Equal(t, 1, slowStore.SaveCnt["foo"])
+}
+
 func TestWithSameStore(t *testing.T) {
 	store := newMapStore()
 	app := New(
@@ -1382,6 +1931,202 @@ func TestAutoAVIF(t *testing.T) {
 	})
 }
 
+func TestAutoJXL(t *testing.T) {
+	factory := func(isAuto bool) *Imagor {
+		return New(
+			WithDebug(true),
+			WithUnsafe(true),
+			WithAutoJXL(isAuto),
+			WithAutoAVIF(true),
+			WithAutoWebP(true),
+			WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+				return NewBlobFromBytes([]byte("foo")), nil
+			})),
+			WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+				return NewBlobFromBytes([]byte(p.Path)), nil
+			})),
+			WithDebug(true))
+	}
+
+	t.Run("supported auto not enabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(
+			http.MethodGet, "https://example.com/unsafe/abc.png", nil)
+		r.Header.Set("Accept", "image/jxl,image/avif,image/webp,image/*,*/*;q=0.8")
+		factory(false).ServeHTTP(w, r)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "filters:format(avif)/abc.png", w.Body.String())
+	})
+	t.Run("supported auto", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(
+			http.MethodGet, "https://example.com/unsafe/abc.png", nil)
+		r.Header.Set("Accept", "image/jxl,image/avif,image/webp,image/*,*/*;q=0.8")
+		factory(true).ServeHTTP(w, r)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		assert.Equal(t, "filters:format(jxl)/abc.png", w.Body.String())
+	})
+	t.Run("not supported fallback", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(
+			http.MethodGet, "https://example.com/unsafe/abc.png", nil)
+		r.Header.Set("Accept", "image/webp,image/*,*/*;q=0.8")
+		factory(true).ServeHTTP(w, r)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "filters:format(webp)/abc.png", w.Body.String())
+	})
+	t.Run("explicit format", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(
+			http.MethodGet, "https://example.com/unsafe/filters:format(png)/abc.png", nil)
+		r.Header.Set("Accept", "image/jxl,image/*,*/*;q=0.8")
+		factory(true).ServeHTTP(w, r)
+		assert.Equal(t, 200, w.Code)
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.Equal(t, "filters:format(png)/abc.png", w.Body.String())
+	})
+}
+
+func TestClientHints(t *testing.T) {
+	resultStore := newMapStore()
+	factory := func(enable bool) *Imagor {
+		return New(
+			WithDebug(true),
+			WithUnsafe(true),
+			WithClientHints(enable),
+			WithClientHintsMaxWidth(2000),
+			WithClientHintsMaxHeight(1500),
+			WithResultStorages(resultStore),
+			WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+				return NewBlobFromBytes([]byte("foo")), nil
+			})),
+			WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+				return NewBlobFromBytes([]byte(p.Path)), nil
+			})))
+	}
+	app := New(WithClientHints(true))
+	assert.Equal(t, DefaultClientHintsMaxSize, app.ClientHintsMaxWidth)
+	assert.Equal(t, DefaultClientHintsMaxSize, app.ClientHintsMaxHeight)
+	t.Run("not enabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/100x50/abc.png", nil)
+		r.Header.Set("Sec-CH-DPR", "2")
+		factory(false).ServeHTTP(w, r)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "100x50/abc.png", w.Body.String())
+		assert.Empty(t, w.Header().Get("Accept-CH"))
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name: "no hints",
+			path: "100x50/abc.png",
+			want: "100x50/abc.png",
+		},
+		{
+			name:    "dpr scales dimensions",
+			path:    "fit-in/100x50/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2"},
+			want:    "fit-in/200x100/abc.png",
+		},
+		{
+			name:    "dpr fraction",
+			path:    "100x0/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "1.5"},
+			want:    "150x0/abc.png",
+		},
+		{
+			name:    "width without dimensions",
+			path:    "abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2", "Sec-CH-Width": "600"},
+			want:    "600x0/abc.png",
+		},
+		{
+			name:    "viewport width without dimensions",
+			path:    "abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2", "Sec-CH-Viewport-Width": "400"},
+			want:    "800x0/abc.png",
+		},
+		{
+			name:    "clamped by viewport width",
+			path:    "600x300/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2", "Sec-CH-Viewport-Width": "400"},
+			want:    "800x400/abc.png",
+		},
+		{
+			name:    "clamped by max width",
+			path:    "1500x1000/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2"},
+			want:    "2000x1333/abc.png",
+		},
+		{
+			name:    "clamped by max height",
+			path:    "500x1000/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "3"},
+			want:    "750x1500/abc.png",
+		},
+		{
+			name:    "flip preserved",
+			path:    "-100x-50/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2"},
+			want:    "-200x-100/abc.png",
+		},
+		{
+			name:    "dpr rounded up",
+			path:    "100x50/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "1.25"},
+			want:    "150x75/abc.png",
+		},
+		{
+			name:    "dpr capped",
+			path:    "100x50/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "8"},
+			want:    "300x150/abc.png",
+		},
+		{
+			name:    "width rounded up",
+			path:    "abc.png",
+			headers: map[string]string{"Sec-CH-Width": "641"},
+			want:    "700x0/abc.png",
+		},
+		{
+			name:    "viewport width rounded up",
+			path:    "abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "2.2", "Sec-CH-Viewport-Width": "375"},
+			want:    "1200x0/abc.png",
+		},
+		{
+			name:    "invalid hints",
+			path:    "100x50/abc.png",
+			headers: map[string]string{"Sec-CH-DPR": "abc", "Sec-CH-Width": "-1"},
+			want:    "100x50/abc.png",
+		},
+	}
+	app = factory(true)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/"+tt.path, nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			app.ServeHTTP(w, r)
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, tt.want, w.Body.String())
+			assert.Equal(t, "Sec-CH-DPR, Sec-CH-Width, Sec-CH-Viewport-Width", w.Header().Get("Accept-CH"))
+			assert.Equal(t, "Sec-CH-DPR, Sec-CH-Width, Sec-CH-Viewport-Width", w.Header().Get("Vary"))
+			time.Sleep(time.Millisecond * 10) // make sure storage reached
+			assert.Equal(t, 1, resultStore.SaveCnt[tt.want])
+		})
+	}
+}
+
 func TestWithTimeout(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.String(), "sleep") {
@@ -1568,3 +2313,117 @@ func (f processorFunc) Startup(_ context.Context) error {
 func (f processorFunc) Shutdown(_ context.Context) error {
 	return nil
 }
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []httpRange
+		err      error
+	}{
+		{"bytes=0-4", []httpRange{{0, 5}}, nil},
+		{"bytes=2-", []httpRange{{2, 8}}, nil},
+		{"bytes=-3", []httpRange{{7, 3}}, nil},
+		{"bytes=-20", []httpRange{{0, 10}}, nil},
+		{"bytes=5-100", []httpRange{{5, 5}}, nil},
+		{"bytes=0-1, 4-5,8-", []httpRange{{0, 2}, {4, 2}, {8, 2}}, nil},
+		{"bytes=0-1,20-30", []httpRange{{0, 2}}, nil},
+		{"bytes=20-30", nil, errRangeNotSatisfiable},
+		{"bytes=-0", nil, errRangeNotSatisfiable},
+		{"bytes=5-4", nil, nil},
+		{"bytes=a-b", nil, nil},
+		{"bytes=5", nil, nil},
+		{"items=0-4", nil, nil},
+	}
+	for _, test := range tests {
+		ranges, err := parseRange(test.header, 10)
+		assert.Equal(t, test.expected, ranges, test.header)
+		assert.Equal(t, test.err, err, test.header)
+	}
+}
+
+func TestRange(t *testing.T) {
+	resultStore := newMapStore()
+	app := New(
+		WithUnsafe(true),
+		WithResultStorages(resultStore),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			return NewBlobFromBytes([]byte("0123456789")), nil
+		})),
+	)
+	do := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "https://example.com/unsafe/foo.txt", nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		app.ServeHTTP(w, r)
+		return w
+	}
+	w := do(http.MethodGet, map[string]string{"Range": "bytes=2-5"})
+	assert.Equal(t, 206, w.Code)
+	assert.Equal(t, "2345", w.Body.String())
+	assert.Equal(t, "bytes 2-5/10", w.Header().Get("Content-Range"))
+	assert.Equal(t, "4", w.Header().Get("Content-Length"))
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=-3"})
+	assert.Equal(t, 206, w.Code)
+	assert.Equal(t, "789", w.Body.String())
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=20-"})
+	assert.Equal(t, 416, w.Code)
+	assert.Equal(t, "bytes */10", w.Header().Get("Content-Range"))
+	assert.Empty(t, w.Body.String())
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=5-4"})
+	assert.Equal(t, 200, w.Code, "invalid range ignored")
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=0-9,0-9"})
+	assert.Equal(t, 200, w.Code, "ranges exceed content")
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = do(http.MethodHead, map[string]string{"Range": "bytes=2-5"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=0-1,8-"})
+	assert.Equal(t, 206, w.Code)
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	for _, expected := range []struct{ body, contentRange string }{
+		{"01", "bytes 0-1/10"},
+		{"89", "bytes 8-9/10"},
+	} {
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, expected.contentRange, part.Header.Get("Content-Range"))
+		assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+		buf, _ := io.ReadAll(part)
+		assert.Equal(t, expected.body, string(buf))
+	}
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+
+	// If-Range by ETag and Last-Modified of result storage
+	assert.Eventually(t, func() bool {
+		return do(http.MethodGet, nil).Header().Get("ETag") != ""
+	}, time.Second, time.Millisecond)
+	w = do(http.MethodGet, nil)
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=2-5", "If-Range": etag})
+	assert.Equal(t, 206, w.Code)
+	assert.Equal(t, "2345", w.Body.String())
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=2-5", "If-Range": lastModified})
+	assert.Equal(t, 206, w.Code)
+	assert.Equal(t, "2345", w.Body.String())
+
+	w = do(http.MethodGet, map[string]string{"Range": "bytes=2-5", "If-Range": `"abcd"`})
+	assert.Equal(t, 200, w.Code, "If-Range mismatch")
+	assert.Equal(t, "0123456789", w.Body.String())
+}
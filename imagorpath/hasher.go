@@ -17,6 +17,12 @@ type ResultStorageHasher interface {
 	HashResult(p Params) string
 }
 
+// ResultStoragePrefixHasher ResultStorageHasher with result keys of the same image sharing a common prefix
+type ResultStoragePrefixHasher interface {
+	ResultStorageHasher
+	HashResultPrefix(image string) string
+}
+
 // StorageHasherFunc StorageHasher handler func
 type StorageHasherFunc func(image string) string
 
@@ -50,8 +56,26 @@ var DigestResultStorageHasher = ResultStorageHasherFunc(func(p Params) string {
 	return hexDigestPath(p.Path)
 })
 
+// suffixResultStorageHasher ResultStorageHasher of result keys prefixed by image without extension
+type suffixResultStorageHasher func(p Params) string
+
+// HashResult implements ResultStorageHasher interface
+func (h suffixResultStorageHasher) HashResult(p Params) string {
+	return h(p)
+}
+
+// HashResultPrefix implements ResultStoragePrefixHasher interface
+func (h suffixResultStorageHasher) HashResultPrefix(image string) string {
+	var dotIdx = strings.LastIndex(image, ".")
+	var slashIdx = strings.LastIndex(image, "/")
+	if dotIdx > -1 && slashIdx < dotIdx {
+		return image[:dotIdx] + "." // /abc/def.
+	}
+	return image + "."
+}
+
 // SuffixResultStorageHasher  ResultStorageHasher using storage path with digest suffix
-var SuffixResultStorageHasher = ResultStorageHasherFunc(func(p Params) string {
+var SuffixResultStorageHasher = suffixResultStorageHasher(func(p Params) string {
 	if p.Path == "" {
 		p.Path = GeneratePath(p)
 	}
@@ -76,7 +100,7 @@ var SuffixResultStorageHasher = ResultStorageHasherFunc(func(p Params) string {
 })
 
 // SizeSuffixResultStorageHasher  ResultStorageHasher using storage path with digest and size suffix
-var SizeSuffixResultStorageHasher = ResultStorageHasherFunc(func(p Params) string {
+var SizeSuffixResultStorageHasher = suffixResultStorageHasher(func(p Params) string {
 	if p.Path == "" {
 		p.Path = GeneratePath(p)
 	}
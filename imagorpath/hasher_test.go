@@ -3,6 +3,7 @@ package imagorpath
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -55,3 +56,19 @@ func TestSuffixResultStorageHasher(t *testing.T) {
 	assert.Equal(t, "example.com/foobar.c80ab0faf85b35a140a8.json", SuffixResultStorageHasher.HashResult(p))
 	assert.Equal(t, "example.com/foobar.c80ab0faf85b35a140a8_17x19.json", SizeSuffixResultStorageHasher.HashResult(p))
 }
+
+func TestResultStoragePrefixHasher(t *testing.T) {
+	for _, h := range []ResultStoragePrefixHasher{SuffixResultStorageHasher, SizeSuffixResultStorageHasher} {
+		assert.Equal(t, "example.com/foobar.", h.HashResultPrefix("example.com/foobar.jpg"))
+		assert.Equal(t, "example.com/foobar.", h.HashResultPrefix("example.com/foobar"))
+		for _, p := range []Params{
+			Parse("166x169/top/example.com/foobar.jpg"),
+			Parse("meta/fit-in/17x19/filters:format(webp)/example.com/foobar.jpg"),
+		} {
+			assert.True(t, strings.HasPrefix(h.HashResult(p), h.HashResultPrefix(p.Image)))
+		}
+	}
+	var h ResultStorageHasher = DigestResultStorageHasher
+	_, ok := h.(ResultStoragePrefixHasher)
+	assert.False(t, ok)
+}
@@ -1,12 +1,14 @@
 package imagorpath
 
 import (
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseGenerate(t *testing.T) {
@@ -358,6 +360,26 @@ func TestParseGenerate(t *testing.T) {
 				Filters:    []Filter{{Name: "some_filter"}},
 			},
 		},
+		{
+			name: "encoder filters",
+			uri:  "fit-in/100x100/filters:format(webp):lossless():effort(6):subsampling(444):progressive():palette(16):strip_metadata()/img.png",
+			params: Params{
+				Path:   "fit-in/100x100/filters:format(webp):lossless():effort(6):subsampling(444):progressive():palette(16):strip_metadata()/img.png",
+				Image:  "img.png",
+				FitIn:  true,
+				Width:  100,
+				Height: 100,
+				Filters: []Filter{
+					{Name: "format", Args: "webp"},
+					{Name: "lossless"},
+					{Name: "effort", Args: "6"},
+					{Name: "subsampling", Args: "444"},
+					{Name: "progressive"},
+					{Name: "palette", Args: "16"},
+					{Name: "strip_metadata"},
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		if test.name == "" {
@@ -418,6 +440,54 @@ func TestHMACSigner(t *testing.T) {
 	assert.Equal(t, signer.Sign("assfasf"), "zb6uWXQxwJDOe_zOgxkuj96Etrsz")
 }
 
+func TestMultiSigner(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	signer := NewMultiSigner(
+		NewDefaultSigner("new"),
+		LegacySigner{Signer: NewDefaultSigner("old")},
+		LegacySigner{Signer: NewDefaultSigner("retired"), RetireAt: now},
+		LegacySigner{Signer: NewDefaultSigner("retiring"), RetireAt: now.Add(time.Hour)},
+	)
+	signer.now = func() time.Time { return now }
+	assert.Equal(t, NewDefaultSigner("new").Sign("foo"), signer.Sign("foo"))
+
+	for secret, expected := range map[string]int{"new": 0, "old": 1, "retired": -1, "retiring": 3, "other": -1} {
+		key, ok := signer.Verify("foo", NewDefaultSigner(secret).Sign("foo"))
+		assert.Equal(t, expected, key, secret)
+		assert.Equal(t, expected >= 0, ok, secret)
+	}
+	key, ok := signer.Verify("bar", NewDefaultSigner("old").Sign("foo"))
+	assert.False(t, ok)
+	assert.Equal(t, -1, key)
+}
+
+func TestEd25519Signer(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	signer := NewEd25519Signer("partner1", key)
+	verifier := NewEd25519Verifier(map[string]ed25519.PublicKey{
+		"partner1": key.Public().(ed25519.PublicKey),
+	})
+	uri := Generate(Params{
+		Width: 300, Height: 200, Image: "foo.jpg",
+	}, signer)
+	assert.True(t, strings.HasPrefix(uri, "partner1:"))
+
+	p := Parse(uri)
+	assert.Equal(t, "partner1", p.KeyID)
+	assert.Len(t, p.Hash, 88)
+	assert.Equal(t, "300x200/foo.jpg", p.Path)
+	assert.Equal(t, "foo.jpg", p.Image)
+	assert.True(t, verifier.VerifyKey(p.KeyID, p.Path, p.Hash))
+	assert.False(t, verifier.VerifyKey("partner2", p.Path, p.Hash))
+	assert.False(t, verifier.VerifyKey(p.KeyID, "300x201/foo.jpg", p.Hash))
+	assert.False(t, verifier.VerifyKey(p.KeyID, p.Path, "!"+p.Hash))
+
+	p = Parse("trim:top-left/foo.jpg")
+	assert.Empty(t, p.KeyID)
+	assert.Empty(t, p.Hash)
+	assert.True(t, p.Trim)
+}
+
 func TestParseFilters(t *testing.T) {
 	filters, img := parseFilters("filters:watermark(s.glbimg.com/filters:label(abc):watermark(aaa.com/fit-in/filters:aaa(bbb))/aaa.jpg,0,0,0):brightness(-50):grayscale()/some/example/img")
 	assert.Equal(t, []Filter{
@@ -456,3 +526,65 @@ func TestParseFilters(t *testing.T) {
 	}, filters)
 	assert.Empty(t, img)
 }
+
+func TestPresets(t *testing.T) {
+	presets := Presets{
+		"card":  "fit-in/300x200/filters:format(webp):quality(80)",
+		"thumb": "/100x100/smart/",
+	}
+	full := Parse("fit-in/300x200/filters:format(webp):quality(80)/foo.jpg")
+	for _, uri := range []string{
+		"preset:card/foo.jpg",
+		"/p/card/foo.jpg",
+	} {
+		p := presets.Parse(uri)
+		assert.Equal(t, full, p, uri)
+	}
+
+	p := presets.Parse("/abcdefghijk/preset:card/foo.jpg")
+	assert.Equal(t, "abcdefghijk", p.Hash)
+	assert.Equal(t, "fit-in/300x200/filters:format(webp):quality(80)/foo.jpg", p.Path)
+
+	p = presets.Parse("/params/unsafe/p/thumb/filters:blur(2)/https://example.com/foo.jpg")
+	assert.Equal(t, Params{
+		Params: true,
+		Unsafe: true,
+		Path:   "100x100/smart/filters:blur(2)/https://example.com/foo.jpg",
+		Image:  "https://example.com/foo.jpg",
+		Width:  100,
+		Height: 100,
+		Smart:  true,
+		Filters: []Filter{
+			{Name: "blur", Args: "2"},
+		},
+	}, p)
+
+	p = presets.Parse("unsafe/p/card/500x0/foo.jpg")
+	assert.Equal(t, "fit-in/500x0/filters:format(webp):quality(80)/foo.jpg", p.Path, "params on top of preset")
+
+	assert.Equal(t, Parse("unsafe/p/hero/foo.jpg"), presets.Parse("unsafe/p/hero/foo.jpg"), "unknown preset")
+	assert.Equal(t, "p/hero/foo.jpg", presets.Parse("unsafe/p/hero/foo.jpg").Image)
+	assert.Equal(t, Parse("unsafe/p/card/foo.jpg"), Presets(nil).Parse("unsafe/p/card/foo.jpg"), "no presets")
+
+	name, ok := presets.Unresolved(presets.Parse("unsafe/p/hero/foo.jpg"))
+	assert.True(t, ok)
+	assert.Equal(t, "hero", name)
+	name, ok = presets.Unresolved(presets.Parse("unsafe/preset:hero"))
+	assert.True(t, ok)
+	assert.Equal(t, "hero", name)
+	for _, uri := range []string{
+		"unsafe/p/card/foo.jpg",
+		"unsafe/fit-in/p/hero/foo.jpg",
+		"unsafe/p%2Fhero%2Ffoo.jpg",
+		"unsafe/foo.jpg",
+	} {
+		_, ok = presets.Unresolved(presets.Parse(uri))
+		assert.False(t, ok, uri)
+	}
+	_, ok = Presets(nil).Unresolved(Parse("unsafe/p/card/foo.jpg"))
+	assert.False(t, ok, "no presets")
+
+	p = presets.Apply(Params{Meta: true}, "unsafe/preset:card/foo.jpg")
+	assert.True(t, p.Meta)
+	assert.Equal(t, "meta/fit-in/300x200/filters:format(webp):quality(80)/foo.jpg", p.Path)
+}
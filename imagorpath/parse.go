@@ -11,8 +11,8 @@ var pathRegex = regexp.MustCompile(
 	"/*" +
 		// params
 		"(params/)?" +
-		// hash
-		"((unsafe/)|([A-Za-z0-9-_=]{8,})/)?" +
+		// hash, or key ID and signature
+		"((unsafe/)|([A-Za-z0-9-_]+):([A-Za-z0-9-_=]{64,})/|([A-Za-z0-9-_=]{8,})/)?" +
 		// path
 		"(.+)?",
 )
@@ -52,7 +52,7 @@ func Parse(path string) Params {
 // Apply Params struct from imagor endpoint URI on top of existing Params
 func Apply(p Params, path string) Params {
 	match := pathRegex.FindStringSubmatch(breaksCleaner.Replace(path))
-	if len(match) < 6 {
+	if len(match) < 8 {
 		return p
 	}
 	index := 1
@@ -62,17 +62,24 @@ func Apply(p Params, path string) Params {
 	index++
 	if match[index+1] == "unsafe/" {
 		p.Unsafe = true
-	} else if len(match[index+2]) > 8 {
-		p.Hash = match[index+2]
+	} else if match[index+2] != "" {
+		p.KeyID = match[index+2]
+		p.Hash = match[index+3]
+	} else if len(match[index+4]) > 8 {
+		p.Hash = match[index+4]
 	}
-	index += 3
+	index += 5
 	p.Path = match[index]
+	return applyParams(p, p.Path)
+}
 
-	match = paramsRegex.FindStringSubmatch(p.Path)
+// applyParams applies image params and filters of path on top of existing Params
+func applyParams(p Params, path string) Params {
+	match := paramsRegex.FindStringSubmatch(path)
 	if len(match) == 0 {
 		return p
 	}
-	index = 1
+	index := 1
 	if match[index] != "" {
 		p.Meta = true
 	}
@@ -0,0 +1,61 @@
+package imagorpath
+
+import (
+	"regexp"
+	"strings"
+)
+
+var presetRegex = regexp.MustCompile("^(p/|preset:)([^/]+)(/|$)")
+
+// Presets named params presets, mapping preset name to params e.g. fit-in/300x200/filters:format(webp).
+// A preset is referenced by path segment preset:<name> or p/<name> in front of the image params
+type Presets map[string]string
+
+// Parse Params struct from imagor endpoint URI, expanding preset reference
+func (ps Presets) Parse(path string) Params {
+	return ps.Apply(Params{}, path)
+}
+
+// Unresolved returns name of the preset referenced by Params path that is not found in Presets.
+// Preset references are only resolved if any Presets configured
+func (ps Presets) Unresolved(p Params) (string, bool) {
+	if len(ps) == 0 {
+		return "", false
+	}
+	match := presetRegex.FindStringSubmatch(p.Path)
+	if len(match) == 0 {
+		return "", false
+	}
+	if _, ok := ps[match[2]]; ok {
+		return "", false
+	}
+	return match[2], true
+}
+
+// Apply Params struct from imagor endpoint URI on top of existing Params, expanding preset reference.
+// Params following the preset reference are applied on top of the preset.
+// Path of the expanded Params is generated from its full form,
+// such that it is signed and hashed the same as the full form.
+// Params of unknown preset reference is left unexpanded, checked by Unresolved
+func (ps Presets) Apply(p Params, path string) Params {
+	applied := Apply(p, path)
+	if len(ps) == 0 {
+		return applied
+	}
+	match := presetRegex.FindStringSubmatch(applied.Path)
+	if len(match) == 0 {
+		return applied
+	}
+	preset, ok := ps[match[2]]
+	if !ok {
+		return applied
+	}
+	p.Params = applied.Params
+	p.Unsafe = applied.Unsafe
+	p.Hash = applied.Hash
+	p.KeyID = applied.KeyID
+	p = applyParams(p, strings.Trim(preset, "/")+"/")
+	p = applyParams(p, applied.Path[len(match[0]):])
+	p.Path = GeneratePath(p)
+	return p
+}
@@ -1,10 +1,12 @@
 package imagorpath
 
 import (
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
 	"hash"
+	"time"
 )
 
 // Signer imagor URL signature signer
@@ -41,3 +43,101 @@ func (s *hmacSigner) Sign(path string) string {
 	}
 	return sig
 }
+
+// Verifier verifies URL signature against multiple keys,
+// optionally implemented by Signer for key rotation
+type Verifier interface {
+	// Verify returns the index of the key that matches signature of path,
+	// and false if none of the active keys matches
+	Verify(path, hash string) (key int, ok bool)
+}
+
+// LegacySigner legacy signer accepted for verification until retire at time,
+// never retires if RetireAt is zero
+type LegacySigner struct {
+	Signer   Signer
+	RetireAt time.Time
+}
+
+// NewMultiSigner signer that signs with the primary signer,
+// and verifies signature against the primary and accepted legacy signers
+func NewMultiSigner(primary Signer, legacy ...LegacySigner) *MultiSigner {
+	return &MultiSigner{
+		Primary: primary,
+		Legacy:  legacy,
+		now:     time.Now,
+	}
+}
+
+// MultiSigner multi-key signer for signature key rotation
+type MultiSigner struct {
+	Primary Signer
+	Legacy  []LegacySigner
+	now     func() time.Time
+}
+
+// Sign signs path with the primary signer
+func (s *MultiSigner) Sign(path string) string {
+	return s.Primary.Sign(path)
+}
+
+// Verify returns 0 if signature matches the primary signer,
+// or the 1-based index of legacy signer that matches and is not yet retired
+func (s *MultiSigner) Verify(path, hash string) (int, bool) {
+	if hmac.Equal([]byte(s.Primary.Sign(path)), []byte(hash)) {
+		return 0, true
+	}
+	now := s.now()
+	for i, legacy := range s.Legacy {
+		if !legacy.RetireAt.IsZero() && !now.Before(legacy.RetireAt) {
+			continue
+		}
+		if hmac.Equal([]byte(legacy.Signer.Sign(path)), []byte(hash)) {
+			return i + 1, true
+		}
+	}
+	return -1, false
+}
+
+// KeyVerifier verifies URL signature by key ID,
+// for asymmetric signatures such as Ed25519
+type KeyVerifier interface {
+	VerifyKey(keyID, path, hash string) bool
+}
+
+// NewEd25519Signer signer using Ed25519 private key,
+// which signature is prefixed by key ID in form of <keyID>:<signature>
+func NewEd25519Signer(keyID string, key ed25519.PrivateKey) Signer {
+	return &ed25519Signer{
+		keyID: keyID,
+		key:   key,
+	}
+}
+
+type ed25519Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(path string) string {
+	return s.keyID + ":" + base64.URLEncoding.EncodeToString(ed25519.Sign(s.key, []byte(path)))
+}
+
+// NewEd25519Verifier key verifier using Ed25519 public keys by key ID
+func NewEd25519Verifier(keys map[string]ed25519.PublicKey) KeyVerifier {
+	return ed25519Verifier(keys)
+}
+
+type ed25519Verifier map[string]ed25519.PublicKey
+
+func (v ed25519Verifier) VerifyKey(keyID, path, hash string) bool {
+	key, ok := v[keyID]
+	if !ok || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := base64.URLEncoding.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, []byte(path), sig)
+}
@@ -245,6 +245,8 @@ func (h *HTTPLoader) newRequest(r *http.Request, method, url string) (*http.Requ
 			req.Header.Set(header, r.Header.Get(header))
 		}
 	}
+	// propagate trace context e.g. traceparent of the loader span
+	imagor.InjectTrace(r.Context(), req.Header)
 	for key, value := range h.OverrideHeaders {
 		req.Header.Set(key, value)
 	}
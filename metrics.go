@@ -0,0 +1,30 @@
+package imagor
+
+import "time"
+
+// Metrics metrics hook that observes imagor internals
+type Metrics interface {
+	// ObserveResultStorage observes Result Storage lookup, hit or miss
+	ObserveResultStorage(hit bool)
+
+	// ObserveLoad observes source image load duration by Loader type
+	ObserveLoad(loader string, duration time.Duration, err error)
+
+	// ObserveProcess observes image processing duration by output format
+	ObserveProcess(format string, duration time.Duration, err error)
+
+	// AddQueueDepth adds delta to the number of requests waiting for process concurrency
+	AddQueueDepth(delta int64)
+
+	// ObserveRejected observes request rejected with ErrTooManyRequests
+	ObserveRejected()
+
+	// ObserveSuppressed observes request suppressed by an identical in-flight request
+	ObserveSuppressed()
+
+	// ObserveBytesIn observes bytes of source image loaded for processing
+	ObserveBytesIn(n int64)
+
+	// ObserveBytesOut observes bytes of response body written
+	ObserveBytesOut(n int64)
+}
@@ -0,0 +1,198 @@
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cshum/imagor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// OTelTracing OpenTelemetry tracing with OTLP/HTTP exporter,
+// implements imagor.Tracer interface
+type OTelTracing struct {
+	Endpoint    string
+	URLPath     string
+	Insecure    bool
+	Headers     map[string]string
+	ServiceName string
+	SampleRatio float64
+	Logger      *zap.Logger
+
+	exporter   *otlptrace.Exporter
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// New create new OTelTracing
+func New(options ...Option) *OTelTracing {
+	t := &OTelTracing{
+		ServiceName: "imagor",
+		SampleRatio: 1,
+		Logger:      zap.NewNop(),
+	}
+	for _, option := range options {
+		option(t)
+	}
+	var opts []otlptracehttp.Option
+	if t.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(t.Endpoint))
+	}
+	if t.URLPath != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(t.URLPath))
+	}
+	if t.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(t.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(t.Headers))
+	}
+	t.exporter = otlptracehttp.NewUnstarted(opts...)
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(t.exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(t.ServiceName),
+			semconv.ServiceVersion(imagor.Version),
+		)),
+	)
+	t.tracer = t.provider.Tracer("github.com/cshum/imagor")
+	t.propagator = propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{})
+	return t
+}
+
+// Startup starts OTLP exporter
+func (t *OTelTracing) Startup(ctx context.Context) error {
+	if err := t.exporter.Start(ctx); err != nil {
+		return err
+	}
+	t.Logger.Info("otel tracing", zap.String("endpoint", t.Endpoint))
+	return nil
+}
+
+// Shutdown flushes pending spans and shuts down OTLP exporter
+func (t *OTelTracing) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+// Start implements imagor.Tracer interface
+func (t *OTelTracing) Start(ctx context.Context, name string) (context.Context, imagor.Span) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, otelSpan{span}
+}
+
+// Extract implements imagor.Tracer interface.
+// Trace context from header is used only if context has no active span
+func (t *OTelTracing) Extract(ctx context.Context, header http.Header) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return t.propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject implements imagor.Tracer interface
+func (t *OTelTracing) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// SetAttribute implements imagor.Span interface
+func (s otelSpan) SetAttribute(key string, value any) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case float64:
+		s.span.SetAttributes(attribute.Float64(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// End implements imagor.Span interface
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// Option OTelTracing option
+type Option func(t *OTelTracing)
+
+// WithEndpoint with OTLP/HTTP collector endpoint host and port option e.g. localhost:4318
+func WithEndpoint(endpoint string) Option {
+	return func(t *OTelTracing) {
+		t.Endpoint = endpoint
+	}
+}
+
+// WithURLPath with OTLP/HTTP collector URL path option, default /v1/traces
+func WithURLPath(urlPath string) Option {
+	return func(t *OTelTracing) {
+		t.URLPath = urlPath
+	}
+}
+
+// WithInsecure with insecure HTTP instead of HTTPS option
+func WithInsecure(insecure bool) Option {
+	return func(t *OTelTracing) {
+		t.Insecure = insecure
+	}
+}
+
+// WithHeaders with OTLP/HTTP request headers option
+func WithHeaders(headers map[string]string) Option {
+	return func(t *OTelTracing) {
+		t.Headers = headers
+	}
+}
+
+// WithServiceName with service name resource option
+func WithServiceName(name string) Option {
+	return func(t *OTelTracing) {
+		if name != "" {
+			t.ServiceName = name
+		}
+	}
+}
+
+// WithSampleRatio with trace ID ratio based sampler option, from 0 to 1
+func WithSampleRatio(ratio float64) Option {
+	return func(t *OTelTracing) {
+		if ratio >= 0 && ratio <= 1 {
+			t.SampleRatio = ratio
+		}
+	}
+}
+
+// WithLogger with logger option
+func WithLogger(logger *zap.Logger) Option {
+	return func(t *OTelTracing) {
+		if logger != nil {
+			t.Logger = logger
+		}
+	}
+}
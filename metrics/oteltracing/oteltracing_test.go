@@ -0,0 +1,141 @@
+package oteltracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"github.com/cshum/imagor/loader/httploader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+type processorFunc func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error)
+
+func (f processorFunc) Startup(context.Context) error { return nil }
+
+func (f processorFunc) Process(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+	return f(ctx, blob, p, load)
+}
+
+func (f processorFunc) Shutdown(context.Context) error { return nil }
+
+type collector struct {
+	sync.Mutex
+	spans map[string]string // span name to trace id
+	errs  map[string]bool
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	buf, _ := io.ReadAll(r.Body)
+	req := &coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(buf, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans[span.Name] = string(span.TraceId)
+				if span.Status != nil && span.Status.Message != "" {
+					c.errs[span.Name] = true
+				}
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestOTelTracing(t *testing.T) {
+	col := &collector{spans: map[string]string{}, errs: map[string]bool{}}
+	colServer := httptest.NewServer(col)
+	defer colServer.Close()
+
+	var traceparent string
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte("foo"))
+	}))
+	defer source.Close()
+
+	tracer := New(
+		WithEndpoint(strings.TrimPrefix(colServer.URL, "http://")),
+		WithInsecure(true),
+		WithServiceName("imagor-test"),
+		WithSampleRatio(1),
+		WithLogger(zap.NewNop()),
+	)
+	assert.Equal(t, "imagor-test", tracer.ServiceName)
+
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithTracer(tracer),
+		imagor.WithLoaders(httploader.New(httploader.WithBaseURL(source.URL))),
+		imagor.WithProcessors(processorFunc(func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+			_, span := imagor.StartSpan(ctx, "test.filter")
+			span.SetAttribute("foo", 1)
+			span.End(errors.New("boom"))
+			return blob, nil
+		})),
+	)
+	require.NoError(t, app.Startup(context.Background()))
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r := httptest.NewRequest(http.MethodGet, "/unsafe/foo.jpg", nil)
+	r.Header.Set("traceparent", parent)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foo", w.Body.String())
+
+	// outgoing loader request carries the incoming trace id
+	assert.True(t, strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"), traceparent)
+	assert.NotEqual(t, parent, traceparent)
+
+	require.NoError(t, app.Shutdown(context.Background()))
+
+	col.Lock()
+	defer col.Unlock()
+	for _, name := range []string{"imagor.Do", "imagor.load", "imagor.process", "test.filter"} {
+		traceID, ok := col.spans[name]
+		assert.True(t, ok, name)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", toHex(traceID), name)
+	}
+	assert.True(t, col.errs["test.filter"])
+	assert.False(t, col.errs["imagor.Do"])
+}
+
+func TestOTelTracingExtract(t *testing.T) {
+	tracer := New()
+	ctx, span := tracer.Start(context.Background(), "foo")
+	defer span.End(nil)
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	// active span takes precedence over header
+	assert.Equal(t, ctx, tracer.Extract(ctx, header))
+
+	header = http.Header{}
+	tracer.Inject(ctx, header)
+	assert.NotEmpty(t, header.Get("traceparent"))
+}
+
+func toHex(s string) string {
+	const hex = "0123456789abcdef"
+	b := make([]byte, 0, len(s)*2)
+	for i := 0; i < len(s); i++ {
+		b = append(b, hex[s[i]>>4], hex[s[i]&0xf])
+	}
+	return string(b)
+}
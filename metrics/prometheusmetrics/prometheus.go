@@ -3,6 +3,7 @@ package prometheusmetrics
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -17,6 +18,69 @@ var (
 		},
 		[]string{"code", "method"},
 	)
+	resultStorageTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "imagor_result_storage_total",
+			Help: "A counter of result storage lookups by hit or miss",
+		},
+		[]string{"result"},
+	)
+	loadDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "imagor_load_duration_seconds",
+			Help: "A histogram of latencies for loading source images by loader type",
+		},
+		[]string{"loader", "status"},
+	)
+	processDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "imagor_process_duration_seconds",
+			Help: "A histogram of latencies for processing images by output format",
+		},
+		[]string{"format", "status"},
+	)
+	queueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "imagor_queue_depth",
+			Help: "Number of requests waiting for process concurrency",
+		},
+	)
+	rejectedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "imagor_rejected_total",
+			Help: "A counter of requests rejected by process queue size limit",
+		},
+	)
+	suppressedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "imagor_suppressed_total",
+			Help: "A counter of requests suppressed by an identical in-flight request",
+		},
+	)
+	bytesInTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "imagor_bytes_in_total",
+			Help: "A counter of source image bytes loaded for processing",
+		},
+	)
+	bytesOutTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "imagor_bytes_out_total",
+			Help: "A counter of response body bytes written",
+		},
+	)
+
+	collectors = []prometheus.Collector{
+		httpRequestDuration,
+		resultStorageTotal,
+		loadDuration,
+		processDuration,
+		queueDepth,
+		rejectedTotal,
+		suppressedTotal,
+		bytesInTotal,
+		bytesOutTotal,
+	}
 )
 
 // PrometheusMetrics wraps the Service with additional http and app lifecycle handling
@@ -51,8 +115,10 @@ func New(options ...Option) *PrometheusMetrics {
 
 // Startup prometheus metrics server
 func (s *PrometheusMetrics) Startup(_ context.Context) error {
-	if err := prometheus.Register(httpRequestDuration); err != nil {
-		return err
+	for _, collector := range collectors {
+		if err := prometheus.Register(collector); err != nil {
+			return err
+		}
 	}
 
 	go func() {
@@ -69,6 +135,64 @@ func (s *PrometheusMetrics) Handle(next http.Handler) http.Handler {
 	return promhttp.InstrumentHandlerDuration(httpRequestDuration, next)
 }
 
+// ObserveResultStorage implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveResultStorage(hit bool) {
+	if hit {
+		resultStorageTotal.WithLabelValues("hit").Inc()
+	} else {
+		resultStorageTotal.WithLabelValues("miss").Inc()
+	}
+}
+
+// ObserveLoad implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveLoad(loader string, duration time.Duration, err error) {
+	loadDuration.WithLabelValues(loader, status(err)).Observe(duration.Seconds())
+}
+
+// ObserveProcess implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveProcess(format string, duration time.Duration, err error) {
+	if format == "" {
+		format = "unknown"
+	}
+	processDuration.WithLabelValues(format, status(err)).Observe(duration.Seconds())
+}
+
+// AddQueueDepth implements imagor.Metrics interface
+func (s *PrometheusMetrics) AddQueueDepth(delta int64) {
+	queueDepth.Add(float64(delta))
+}
+
+// ObserveRejected implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveRejected() {
+	rejectedTotal.Inc()
+}
+
+// ObserveSuppressed implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveSuppressed() {
+	suppressedTotal.Inc()
+}
+
+// ObserveBytesIn implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveBytesIn(n int64) {
+	if n > 0 {
+		bytesInTotal.Add(float64(n))
+	}
+}
+
+// ObserveBytesOut implements imagor.Metrics interface
+func (s *PrometheusMetrics) ObserveBytesOut(n int64) {
+	if n > 0 {
+		bytesOutTotal.Add(float64(n))
+	}
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
 // Option PrometheusMetrics option
 type Option func(s *PrometheusMetrics)
 
@@ -1,10 +1,14 @@
 package prometheusmetrics
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -33,3 +37,42 @@ func TestWithOption(t *testing.T) {
 		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
 	})
 }
+
+func TestObserve(t *testing.T) {
+	v := New()
+	v.ObserveResultStorage(true)
+	v.ObserveResultStorage(false)
+	v.ObserveResultStorage(false)
+	assert.Equal(t, float64(1), testutil.ToFloat64(resultStorageTotal.WithLabelValues("hit")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(resultStorageTotal.WithLabelValues("miss")))
+
+	v.ObserveLoad("httploader.HTTPLoader", time.Millisecond, nil)
+	v.ObserveLoad("httploader.HTTPLoader", time.Millisecond, errors.New("boom"))
+	v.ObserveProcess("webp", time.Millisecond, nil)
+	v.ObserveProcess("", time.Millisecond, errors.New("boom"))
+	assert.Equal(t, 2, testutil.CollectAndCount(loadDuration))
+	assert.Equal(t, 2, testutil.CollectAndCount(processDuration))
+	assert.NoError(t, testutil.CollectAndCompare(processDuration, strings.NewReader(`
+# HELP imagor_process_duration_seconds A histogram of latencies for processing images by output format
+# TYPE imagor_process_duration_seconds histogram
+imagor_process_duration_seconds_count{format="unknown",status="error"} 1
+imagor_process_duration_seconds_count{format="webp",status="ok"} 1
+`), "imagor_process_duration_seconds_count"))
+
+	v.AddQueueDepth(1)
+	v.AddQueueDepth(1)
+	v.AddQueueDepth(-1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(queueDepth))
+
+	v.ObserveRejected()
+	v.ObserveSuppressed()
+	v.ObserveSuppressed()
+	assert.Equal(t, float64(1), testutil.ToFloat64(rejectedTotal))
+	assert.Equal(t, float64(2), testutil.ToFloat64(suppressedTotal))
+
+	v.ObserveBytesIn(100)
+	v.ObserveBytesOut(20)
+	v.ObserveBytesOut(0)
+	assert.Equal(t, float64(100), testutil.ToFloat64(bytesInTotal))
+	assert.Equal(t, float64(20), testutil.ToFloat64(bytesOutTotal))
+}
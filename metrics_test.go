@@ -0,0 +1,193 @@
+package imagor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cshum/imagor/imagorpath"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMetrics struct {
+	l          sync.Mutex
+	hit        int
+	miss       int
+	loads      map[string]int
+	processes  map[string]int
+	queueDepth int64
+	maxQueue   int64
+	rejected   int
+	suppressed int
+	bytesIn    int64
+	bytesOut   int64
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{loads: map[string]int{}, processes: map[string]int{}}
+}
+
+func (m *testMetrics) ObserveResultStorage(hit bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if hit {
+		m.hit++
+	} else {
+		m.miss++
+	}
+}
+
+func (m *testMetrics) ObserveLoad(loader string, _ time.Duration, err error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.loads[fmt.Sprintf("%s:%v", loader, err)]++
+}
+
+func (m *testMetrics) ObserveProcess(format string, _ time.Duration, err error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.processes[fmt.Sprintf("%s:%v", format, err)]++
+}
+
+func (m *testMetrics) AddQueueDepth(delta int64) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.queueDepth += delta
+	if m.queueDepth > m.maxQueue {
+		m.maxQueue = m.queueDepth
+	}
+}
+
+func (m *testMetrics) ObserveRejected() {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.rejected++
+}
+
+func (m *testMetrics) ObserveSuppressed() {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.suppressed++
+}
+
+func (m *testMetrics) ObserveBytesIn(n int64) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.bytesIn += n
+}
+
+func (m *testMetrics) ObserveBytesOut(n int64) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.bytesOut += n
+}
+
+func TestWithMetrics(t *testing.T) {
+	metrics := newTestMetrics()
+	resultStore := newMapStore()
+	app := New(
+		WithUnsafe(true),
+		WithMetrics(metrics),
+		WithResultStorages(resultStore),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			if image == "bar.jpg" {
+				return nil, ErrNotFound
+			}
+			return NewBlobFromBytes([]byte("foo")), nil
+		})),
+		WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+			return NewBlobFromBytes([]byte("GIF89a" + strings.Repeat(" ", 30))), nil
+		})),
+	)
+	assert.Equal(t, metrics, app.Metrics)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo.jpg", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Eventually(t, func() bool {
+		resultStore.l.RLock()
+		defer resultStore.l.RUnlock()
+		return resultStore.Map["foo.jpg"] != nil
+	}, time.Second, time.Millisecond)
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo.jpg", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/bar.jpg", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	metrics.l.Lock()
+	defer metrics.l.Unlock()
+	assert.Equal(t, 1, metrics.hit)
+	assert.Equal(t, 2, metrics.miss)
+	assert.Equal(t, map[string]int{
+		"loaderFunc:<nil>":                 1,
+		"loaderFunc:imagor: 404 not found": 1,
+	}, metrics.loads)
+	assert.Equal(t, map[string]int{"gif:<nil>": 1}, metrics.processes)
+	assert.Equal(t, int64(3), metrics.bytesIn)
+	assert.Equal(t, int64(36*2), metrics.bytesOut)
+}
+
+func TestWithMetricsQueue(t *testing.T) {
+	n := 10
+	conn := 2
+	size := 3
+	metrics := newTestMetrics()
+	app := New(
+		WithUnsafe(true),
+		WithMetrics(metrics),
+		WithProcessQueueSize(int64(size)),
+		WithProcessConcurrency(int64(conn)),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			time.Sleep(time.Millisecond * 10)
+			return NewBlobFromBytes([]byte(image)), nil
+		})),
+	)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(
+				http.MethodGet, fmt.Sprintf("https://example.com/unsafe/%d", i), nil))
+		}(i)
+	}
+	wg.Wait()
+	metrics.l.Lock()
+	defer metrics.l.Unlock()
+	assert.Equal(t, n-size-conn, metrics.rejected)
+	assert.Equal(t, int64(0), metrics.queueDepth)
+	assert.True(t, metrics.maxQueue > 0)
+}
+
+func TestWithMetricsSuppressed(t *testing.T) {
+	n := 5
+	metrics := newTestMetrics()
+	app := New(WithMetrics(metrics))
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := app.suppress(context.Background(), "a", func(ctx context.Context, _ func(*Blob, error)) (*Blob, error) {
+				time.Sleep(time.Millisecond * 20)
+				return NewEmptyBlob(), nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	metrics.l.Lock()
+	defer metrics.l.Unlock()
+	assert.Equal(t, n-1, metrics.suppressed)
+}
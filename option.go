@@ -138,6 +138,19 @@ func WithProcessQueueSize(size int64) Option {
 	}
 }
 
+// WithSharedProcessQueue shares process concurrency and queue of another Imagor,
+// such that Imagor instances sharing the same processors are limited as a whole
+func WithSharedProcessQueue(other *Imagor) Option {
+	return func(app *Imagor) {
+		if other != nil && other.sema != nil {
+			app.ProcessConcurrency = other.ProcessConcurrency
+			app.ProcessQueueSize = other.ProcessQueueSize
+			app.sema = other.sema
+			app.queueSema = other.queueSema
+		}
+	}
+}
+
 // WithUnsafe with unsafe option
 func WithUnsafe(unsafe bool) Option {
 	return func(app *Imagor) {
@@ -152,6 +165,39 @@ func WithAutoWebP(enable bool) Option {
 	}
 }
 
+// WithAutoJXL experimental with auto JPEG XL option based on browser Accept header
+func WithAutoJXL(enable bool) Option {
+	return func(app *Imagor) {
+		app.AutoJXL = enable
+	}
+}
+
+// WithClientHints with client hints option, scaling image dimensions based on browser
+// Sec-CH-DPR, Sec-CH-Width and Sec-CH-Viewport-Width headers
+func WithClientHints(enable bool) Option {
+	return func(app *Imagor) {
+		app.ClientHints = enable
+	}
+}
+
+// WithClientHintsMaxWidth with maximum image width scaled by client hints
+func WithClientHintsMaxWidth(width int) Option {
+	return func(app *Imagor) {
+		if width > 0 {
+			app.ClientHintsMaxWidth = width
+		}
+	}
+}
+
+// WithClientHintsMaxHeight with maximum image height scaled by client hints
+func WithClientHintsMaxHeight(height int) Option {
+	return func(app *Imagor) {
+		if height > 0 {
+			app.ClientHintsMaxHeight = height
+		}
+	}
+}
+
 // WithAutoAVIF experimental with auto AVIF option based on browser Accept header
 func WithAutoAVIF(enable bool) Option {
 	return func(app *Imagor) {
@@ -194,6 +240,58 @@ func WithDisableParamsEndpoint(disabled bool) Option {
 	}
 }
 
+// WithUploadEnabled with upload option, enabling POST and PUT request body as source image
+func WithUploadEnabled(enabled bool) Option {
+	return func(app *Imagor) {
+		app.UploadEnabled = enabled
+	}
+}
+
+// WithUploadMaxSize with maximum upload request body size in bytes
+func WithUploadMaxSize(size int64) Option {
+	return func(app *Imagor) {
+		if size > 0 {
+			app.UploadMaxSize = size
+		}
+	}
+}
+
+// WithAdminSecret with admin secret option, enabling admin endpoints authenticated by bearer token
+func WithAdminSecret(secret string) Option {
+	return func(app *Imagor) {
+		app.AdminSecret = secret
+	}
+}
+
+// WithPresets with named presets option, mapping preset name to imagor params e.g. fit-in/300x200.
+// Presets are referenced in URL by preset:<name> or p/<name>, and warmed up by Warm
+func WithPresets(presets map[string]string) Option {
+	return func(app *Imagor) {
+		if len(presets) > 0 {
+			if app.Presets == nil {
+				app.Presets = imagorpath.Presets{}
+			}
+			for name, params := range presets {
+				app.Presets[name] = params
+			}
+		}
+	}
+}
+
+// WithTracer with tracing hook option
+func WithTracer(tracer Tracer) Option {
+	return func(app *Imagor) {
+		app.Tracer = tracer
+	}
+}
+
+// WithMetrics with metrics hook option
+func WithMetrics(metrics Metrics) Option {
+	return func(app *Imagor) {
+		app.Metrics = metrics
+	}
+}
+
 // WithDebug with debug option
 func WithDebug(debug bool) Option {
 	return func(app *Imagor) {
@@ -227,3 +325,11 @@ func WithSigner(signer imagorpath.Signer) Option {
 		}
 	}
 }
+
+// WithKeyVerifier with key verifier option for URL signature with key ID,
+// e.g. Ed25519 signatures verified by public keys
+func WithKeyVerifier(verifier imagorpath.KeyVerifier) Option {
+	return func(app *Imagor) {
+		app.KeyVerifier = verifier
+	}
+}
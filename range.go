@@ -0,0 +1,176 @@
+package imagor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// httpRange byte range of HTTP Range request
+type httpRange struct {
+	start, length int64
+}
+
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// parseRange parses Range header of content size.
+// Returns nil if Range header is invalid and should be ignored,
+// or errRangeNotSatisfiable if none of the ranges overlaps the content
+func parseRange(s string, size int64) ([]httpRange, error) {
+	const b = "bytes="
+	if !strings.HasPrefix(s, b) {
+		return nil, nil
+	}
+	var ranges []httpRange
+	var noOverlap bool
+	for _, ra := range strings.Split(s[len(b):], ",") {
+		ra = strings.TrimSpace(ra)
+		if ra == "" {
+			continue
+		}
+		startStr, endStr, ok := strings.Cut(ra, "-")
+		if !ok {
+			return nil, nil
+		}
+		startStr, endStr = strings.TrimSpace(startStr), strings.TrimSpace(endStr)
+		var r httpRange
+		if startStr == "" {
+			// suffix range of the last n bytes
+			n, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || n < 0 {
+				return nil, nil
+			}
+			if n == 0 {
+				noOverlap = true
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r.start = size - n
+			r.length = n
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, nil
+			}
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+			r.start = start
+			if endStr == "" {
+				r.length = size - start
+			} else {
+				end, err := strconv.ParseInt(endStr, 10, 64)
+				if err != nil || start > end {
+					return nil, nil
+				}
+				if end >= size {
+					end = size - 1
+				}
+				r.length = end - start + 1
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 && noOverlap {
+		return nil, errRangeNotSatisfiable
+	}
+	return ranges, nil
+}
+
+// checkIfRange returns true if If-Range header is absent,
+// or matches the strong ETag or Last-Modified of response
+func checkIfRange(r *http.Request, h http.Header) bool {
+	ir := r.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") && ir == etag {
+		return true
+	}
+	if lm := h.Get("Last-Modified"); lm != "" && ir == lm {
+		return true
+	}
+	return false
+}
+
+// writeRange writes 206 Partial Content of Blob for Range request,
+// with multipart/byteranges for multiple ranges.
+// Returns false if response should be written in full
+func writeRange(w http.ResponseWriter, r *http.Request, blob *Blob) (n int64, ok bool) {
+	size := blob.Size()
+	if size <= 0 {
+		return
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader == "" || r.Method != http.MethodGet || !checkIfRange(r, w.Header()) {
+		return
+	}
+	ranges, err := parseRange(rangeHeader, size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return 0, true
+	}
+	var total int64
+	for _, ra := range ranges {
+		total += ra.length
+	}
+	if len(ranges) == 0 || total > size {
+		// overlapping ranges that exceed the content, serve in full
+		return
+	}
+	reader, _, err := blob.NewReadSeeker()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	if len(ranges) == 1 {
+		ra := ranges[0]
+		if _, err = reader.Seek(ra.start, io.SeekStart); err != nil {
+			return
+		}
+		w.Header().Set("Content-Range", ra.contentRange(size))
+		w.Header().Set("Content-Length", strconv.FormatInt(ra.length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		n, _ = io.CopyN(w, reader, ra.length)
+		return n, true
+	}
+	contentType := w.Header().Get("Content-Type")
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusPartialContent)
+	for _, ra := range ranges {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {contentType},
+			"Content-Range": {ra.contentRange(size)},
+		})
+		if err != nil {
+			return n, true
+		}
+		if _, err = reader.Seek(ra.start, io.SeekStart); err != nil {
+			return n, true
+		}
+		written, err := io.CopyN(part, reader, ra.length)
+		n += written
+		if err != nil {
+			return n, true
+		}
+	}
+	_ = mw.Close()
+	return n, true
+}
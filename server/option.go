@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rs/cors"
@@ -115,3 +116,51 @@ func WithMetrics(metrics Metrics) Option {
 		s.Metrics = metrics
 	}
 }
+
+// WithTLS with TLS certificate and key file option
+func WithTLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.CertFile = certFile
+		s.KeyFile = keyFile
+	}
+}
+
+// WithTLSClientCA with client CA bundle file option, enables mutual TLS
+func WithTLSClientCA(clientCAFile string) Option {
+	return func(s *Server) {
+		s.ClientCAFile = clientCAFile
+	}
+}
+
+// WithTLSReloadInterval with TLS certificate file change polling interval option
+func WithTLSReloadInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		if interval > 0 {
+			s.TLSReloadInterval = interval
+		}
+	}
+}
+
+// WithTenants with multi-tenant option, that routes requests to Tenant handler
+// by Host header or path prefix, in order of the tenants given.
+// Requests not matching any Tenant are served by the Server App
+func WithTenants(tenants ...Tenant) Option {
+	return func(s *Server) {
+		for _, t := range tenants {
+			t.PathPrefix = strings.TrimSuffix(t.PathPrefix, "/")
+			if t.Handler != nil && (len(t.Hosts) > 0 || t.PathPrefix != "") {
+				s.Tenants = append(s.Tenants, t)
+			}
+		}
+	}
+}
+
+// WithRateLimiter with token bucket rate limiter option,
+// that applies to the Server App and tenants excluding utility routes
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(s *Server) {
+		if limiter != nil && limiter.Rate > 0 {
+			s.RateLimiter = limiter
+		}
+	}
+}
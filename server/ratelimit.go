@@ -0,0 +1,176 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+)
+
+// RateLimitKey returns rate limit key of request
+type RateLimitKey func(r *http.Request) string
+
+// RateLimitByIP rate limit key by client real IP.
+// X-Real-Ip and X-Forwarded-For headers are trusted,
+// which should only be used behind a reverse proxy that overwrites them
+func RateLimitByIP(r *http.Request) string {
+	return RealIP(r)
+}
+
+// RateLimitByRemoteAddr rate limit key by IP of the connection remote address,
+// ignoring X-Real-Ip and X-Forwarded-For headers that can be set by clients
+func RateLimitByRemoteAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// RateLimitBySignature rate limit key by key ID of URL signature,
+// fallback to client real IP if URL is not signed by key ID, such as HMAC signed or unsafe URL
+func RateLimitBySignature(r *http.Request) string {
+	if p := imagorpath.Parse(r.URL.EscapedPath()); p.KeyID != "" {
+		return "key:" + p.KeyID
+	}
+	return RealIP(r)
+}
+
+// RateLimiter token bucket rate limiter by rate limit key
+type RateLimiter struct {
+	// Rate number of tokens refilled per second
+	Rate float64
+	// Burst maximum number of tokens of a bucket
+	Burst int
+	// Key rate limit key of request, default by client real IP
+	Key RateLimitKey
+	// ExemptResultStorage takes token only on result storage miss before processing
+	// or loading source of raw, such that result storage hits are not rate limited.
+	// Params endpoint that does not go through processing takes token upfront
+	ExemptResultStorage bool
+
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	l         sync.Mutex
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates token bucket rate limiter of refill rate per second and burst
+func NewRateLimiter(rate float64, burst int, key RateLimitKey, exemptResultStorage bool) *RateLimiter {
+	if key == nil {
+		key = RateLimitByIP
+	}
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &RateLimiter{
+		Rate:                rate,
+		Burst:               burst,
+		Key:                 key,
+		ExemptResultStorage: exemptResultStorage,
+		buckets:             map[string]*bucket{},
+		now:                 time.Now,
+	}
+}
+
+// refill returns the bucket of key refilled up to now, sweeping buckets already full.
+// Lock must be held
+func (l *RateLimiter) refill(key string) *bucket {
+	now := l.now()
+	if now.Sub(l.lastSweep) > time.Minute {
+		l.lastSweep = now
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+	return b
+}
+
+// take takes a token from bucket of key,
+// returns if allowed, remaining tokens and duration until the bucket is full
+func (l *RateLimiter) take(key string, peek bool) (ok bool, remaining int, reset time.Duration) {
+	l.l.Lock()
+	defer l.l.Unlock()
+	b := l.refill(key)
+	if b.tokens >= 1 {
+		ok = true
+		if !peek {
+			b.tokens--
+		}
+	}
+	remaining = int(b.tokens)
+	if l.Rate > 0 {
+		reset = time.Duration((float64(l.Burst) - b.tokens) / l.Rate * float64(time.Second))
+	}
+	return
+}
+
+// Handle HTTP middleware that rate limits requests
+func (l *RateLimiter) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := l.Key(r)
+		peek := l.ExemptResultStorage && !imagorpath.Parse(r.URL.EscapedPath()).Params
+		ok, remaining, reset := l.take(key, peek)
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(l.Burst))
+		l.setRemaining(h, remaining, reset)
+		if peek {
+			var once sync.Once
+			var err error
+			next.ServeHTTP(w, r.WithContext(imagor.WithProcessLimit(r.Context(), func() error {
+				once.Do(func() {
+					// headers reflect the bucket after token taken
+					ok, remaining, reset := l.take(key, false)
+					l.setRemaining(h, remaining, reset)
+					if !ok {
+						h.Set("Retry-After", l.retryAfter())
+						err = imagor.ErrTooManyRequests
+					}
+				})
+				return err
+			})))
+			return
+		}
+		if !ok {
+			h.Set("Retry-After", l.retryAfter())
+			w.WriteHeader(http.StatusTooManyRequests)
+			writeJSON(w, r, imagor.ErrTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRemaining sets RateLimit-Remaining and RateLimit-Reset headers of bucket state
+func (l *RateLimiter) setRemaining(h http.Header, remaining int, reset time.Duration) {
+	h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+}
+
+// retryAfter returns Retry-After header value in seconds until next token refill
+func (l *RateLimiter) retryAfter() string {
+	retryAfter := 1.0
+	if l.Rate > 0 {
+		retryAfter = math.Ceil(1 / l.Rate)
+	}
+	return strconv.Itoa(int(retryAfter))
+}
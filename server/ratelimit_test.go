@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"github.com/cshum/imagor/storage/memorystorage"
+	"github.com/stretchr/testify/assert"
+)
+
+type processorFunc func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error)
+
+func (f processorFunc) Process(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+	return f(ctx, blob, p, load)
+}
+
+func (f processorFunc) Startup(_ context.Context) error {
+	return nil
+}
+
+func (f processorFunc) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(0.5, 2, nil, false)
+	limiter.now = func() time.Time { return now }
+	s := New(&textService{textHandler("ok")}, WithRateLimiter(limiter))
+
+	do := func(ip, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil)
+		r.RemoteAddr = ip + ":1234"
+		s.Handler.ServeHTTP(w, r)
+		return w
+	}
+	w := do("1.1.1.1", "/unsafe/foo.jpg")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+
+	w = do("1.1.1.1", "/unsafe/foo.jpg")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "4", w.Header().Get("RateLimit-Reset"))
+
+	w = do("1.1.1.1", "/unsafe/foo.jpg")
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"message":"too many requests","status":429}`, w.Body.String())
+
+	assert.Equal(t, 200, do("2.2.2.2", "/unsafe/foo.jpg").Code, "keyed by IP")
+	assert.Equal(t, 200, do("1.1.1.1", "/healthcheck").Code, "utility routes not limited")
+
+	now = now.Add(time.Second * 2)
+	assert.Equal(t, 200, do("1.1.1.1", "/unsafe/foo.jpg").Code, "refilled")
+	assert.Equal(t, 429, do("1.1.1.1", "/unsafe/foo.jpg").Code)
+
+	now = now.Add(time.Minute * 2)
+	assert.Equal(t, 200, do("3.3.3.3", "/unsafe/foo.jpg").Code)
+	limiter.l.Lock()
+	assert.Len(t, limiter.buckets, 1, "full buckets swept")
+	limiter.l.Unlock()
+
+	assert.Nil(t, New(&textService{textHandler("ok")}, WithRateLimiter(NewRateLimiter(0, 0, nil, false))).RateLimiter)
+}
+
+func TestRateLimitBySignature(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet,
+		"https://example.com/partner1:"+strings.Repeat("a", 88)+"/foo.jpg", nil)
+	r.RemoteAddr = "1.1.1.1:1234"
+	assert.Equal(t, "key:partner1", RateLimitBySignature(r))
+	r = httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo.jpg", nil)
+	r.RemoteAddr = "1.1.1.1:1234"
+	assert.Equal(t, "1.1.1.1", RateLimitBySignature(r))
+	assert.Equal(t, "1.1.1.1", RateLimitByIP(r))
+
+	r.Header.Set("X-Forwarded-For", "8.8.8.8")
+	assert.Equal(t, "8.8.8.8", RateLimitByIP(r))
+	assert.Equal(t, "1.1.1.1", RateLimitByRemoteAddr(r), "ignores forwarded headers")
+	r.RemoteAddr = "1.1.1.1"
+	assert.Equal(t, "1.1.1.1", RateLimitByRemoteAddr(r))
+}
+
+func TestRateLimiterExemptResultStorage(t *testing.T) {
+	var processed int64
+	resultStorage := memorystorage.New(1 << 20)
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return imagor.NewBlobFromBytes([]byte(image)), nil
+		})),
+		imagor.WithProcessors(processorFunc(func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+			atomic.AddInt64(&processed, 1)
+			return blob, nil
+		})),
+		imagor.WithResultStorages(resultStorage),
+	)
+	limiter := NewRateLimiter(0.001, 1, RateLimitByIP, true)
+	s := New(app, WithRateLimiter(limiter))
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil))
+		return w
+	}
+	w := do("/unsafe/100x100/foo.jpg")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), "token taken on processing")
+	assert.Eventually(t, func() bool {
+		_, err := resultStorage.Get(httptest.NewRequest(http.MethodGet, "/", nil), "100x100/foo.jpg")
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		w = do("/unsafe/100x100/foo.jpg")
+		assert.Equal(t, 200, w.Code, "result storage hit exempted")
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	}
+	assert.Equal(t, int64(1), atomic.LoadInt64(&processed))
+
+	w = do("/unsafe/200x200/foo.jpg")
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "1000", w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"message":"too many requests","status":429}`, w.Body.String())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&processed))
+}
+
+func TestRateLimiterExemptResultStorageRawParams(t *testing.T) {
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return imagor.NewBlobFromBytes([]byte(image)), nil
+		})),
+		imagor.WithResultStorages(memorystorage.New(1<<20)),
+	)
+	s := New(app, WithRateLimiter(NewRateLimiter(0.001, 2, RateLimitByIP, true)))
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil))
+		return w
+	}
+	w := do("/unsafe/filters:raw()/foo.jpg")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"), "raw takes token")
+	w = do("/params/unsafe/100x100/foo.jpg")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), "params takes token")
+	w = do("/unsafe/filters:raw()/foo.jpg")
+	assert.Equal(t, 429, w.Code)
+	w = do("/params/unsafe/100x100/foo.jpg")
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "1000", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterExemptResultStorageSuppressed(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithLoaders(loaderFunc(func(r *http.Request, image string) (*imagor.Blob, error) {
+			return imagor.NewBlobFromBytes([]byte(image)), nil
+		})),
+		imagor.WithProcessors(processorFunc(func(ctx context.Context, blob *imagor.Blob, p imagorpath.Params, load imagor.LoadFunc) (*imagor.Blob, error) {
+			if p.Image == "bar.jpg" {
+				close(started)
+				<-release
+			}
+			return blob, nil
+		})),
+		imagor.WithResultStorages(memorystorage.New(1<<20)),
+	)
+	s := New(app, WithRateLimiter(NewRateLimiter(0.001, 1, RateLimitByRemoteAddr, true)))
+	do := func(ip, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil)
+		r.RemoteAddr = ip + ":1234"
+		s.Handler.ServeHTTP(w, r)
+		return w
+	}
+	assert.Equal(t, 200, do("1.1.1.1", "/unsafe/foo.jpg").Code)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- do("2.2.2.2", "/unsafe/bar.jpg")
+	}()
+	<-started
+	// limited client does not share the processing in flight
+	assert.Equal(t, 429, do("1.1.1.1", "/unsafe/bar.jpg").Code)
+	close(release)
+	assert.Equal(t, 200, (<-done).Code)
+}
@@ -39,17 +39,21 @@ type Metrics interface {
 // Server wraps the Service with additional http and app lifecycle handling
 type Server struct {
 	http.Server
-	App             Service
-	Address         string
-	Port            int
-	CertFile        string
-	KeyFile         string
-	PathPrefix      string
-	StartupTimeout  time.Duration
-	ShutdownTimeout time.Duration
-	Logger          *zap.Logger
-	Debug           bool
-	Metrics         Metrics
+	App               Service
+	Address           string
+	Port              int
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string
+	TLSReloadInterval time.Duration
+	PathPrefix        string
+	StartupTimeout    time.Duration
+	ShutdownTimeout   time.Duration
+	Logger            *zap.Logger
+	Debug             bool
+	Metrics           Metrics
+	Tenants           []Tenant
+	RateLimiter       *RateLimiter
 }
 
 // New create new Server
@@ -63,8 +67,8 @@ func New(app Service, options ...Option) *Server {
 	s.Logger = zap.NewNop()
 
 	// build up middleware handlers in reverse order
-	// Handler: application
-	s.Handler = s.App
+	// Handler: application, or tenant application by host or path prefix
+	s.Handler = http.HandlerFunc(s.serveApp)
 
 	// Handler: utility routes
 	s.Handler = pathHandler(http.MethodGet, map[string]http.HandlerFunc{
@@ -108,6 +112,12 @@ func (s *Server) Run() {
 func (s *Server) RunContext(ctx context.Context) {
 	s.startup(ctx)
 
+	if s.isTLS() {
+		if err := s.setupTLS(ctx); err != nil {
+			s.Logger.Fatal("tls", zap.Error(err))
+		}
+	}
+
 	go func() {
 		if err := s.listenAndServe(); err != nil && err != http.ErrServerClosed {
 			s.Logger.Fatal("listen", zap.Error(err))
@@ -130,6 +140,26 @@ func isNil(c any) bool {
 	return c == nil || (reflect.ValueOf(c).Kind() == reflect.Ptr && reflect.ValueOf(c).IsNil())
 }
 
+// serveApp serves request by the first matching Tenant otherwise the Server App,
+// through rate limiter if enabled
+func (s *Server) serveApp(w http.ResponseWriter, r *http.Request) {
+	var h http.Handler = s.App
+	var prefix string
+	for i := range s.Tenants {
+		if t := &s.Tenants[i]; t.match(r) {
+			h, prefix = t.Handler, t.PathPrefix
+			break
+		}
+	}
+	if s.RateLimiter != nil {
+		h = s.RateLimiter.Handle(h)
+	}
+	if prefix != "" {
+		h = http.StripPrefix(prefix, h)
+	}
+	h.ServeHTTP(w, r)
+}
+
 func (s *Server) startup(ctx context.Context) {
 	ctx, cancel := context.WithTimeout(ctx, s.StartupTimeout)
 	defer cancel()
@@ -156,8 +186,9 @@ func (s *Server) shutdown(ctx context.Context) {
 }
 
 func (s *Server) listenAndServe() error {
-	if s.CertFile != "" && s.KeyFile != "" {
-		return s.ListenAndServeTLS(s.CertFile, s.KeyFile)
+	if s.isTLS() {
+		// certificates served by TLSConfig GetCertificate for reloading
+		return s.ListenAndServeTLS("", "")
 	}
 	return s.ListenAndServe()
 }
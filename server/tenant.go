@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Tenant http.Handler that serves requests matching Hosts and PathPrefix.
+// Tenant Handler does not go through the Server Startup and Shutdown lifecycle,
+// and is expected to share resources such as processors with the Server App
+type Tenant struct {
+	Name       string
+	Hosts      []string
+	PathPrefix string
+	Handler    http.Handler
+}
+
+// match returns true if request matches Host header and path prefix of Tenant
+func (t *Tenant) match(r *http.Request) bool {
+	if len(t.Hosts) > 0 {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		var matched bool
+		for _, h := range t.Hosts {
+			if strings.EqualFold(h, host) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if t.PathPrefix != "" {
+		path := r.URL.Path
+		return path == t.PathPrefix || strings.HasPrefix(path, t.PathPrefix+"/")
+	}
+	return len(t.Hosts) > 0
+}
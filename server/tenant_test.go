@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type textService struct {
+	http.Handler
+}
+
+func (s *textService) Startup(_ context.Context) error {
+	return nil
+}
+
+func (s *textService) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func textHandler(text string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(text + ":" + r.URL.Path))
+	})
+}
+
+func TestWithTenants(t *testing.T) {
+	s := New(&textService{textHandler("default")},
+		WithPathPrefix("/imagor"),
+		WithTenants(
+			Tenant{Name: "a", Hosts: []string{"a.example.com"}, Handler: textHandler("a")},
+			Tenant{Name: "b", Hosts: []string{"b.example.com"}, PathPrefix: "/b/", Handler: textHandler("b")},
+			Tenant{Name: "c", PathPrefix: "/c", Handler: textHandler("c")},
+			Tenant{Name: "invalid", PathPrefix: "/", Handler: textHandler("invalid")},
+			Tenant{Name: "nil", Hosts: []string{"nil.example.com"}},
+		))
+	assert.Len(t, s.Tenants, 3)
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://a.example.com/imagor/unsafe/foo.jpg", "a:/unsafe/foo.jpg"},
+		{"https://A.EXAMPLE.COM:8000/imagor/unsafe/foo.jpg", "a:/unsafe/foo.jpg"},
+		{"https://a.example.com/imagor/c/unsafe/foo.jpg", "a:/c/unsafe/foo.jpg"},
+		{"https://b.example.com/imagor/b/unsafe/foo.jpg", "b:/unsafe/foo.jpg"},
+		{"https://b.example.com/imagor/unsafe/foo.jpg", "default:/unsafe/foo.jpg"},
+		{"https://example.com/imagor/b/unsafe/foo.jpg", "default:/b/unsafe/foo.jpg"},
+		{"https://example.com/imagor/c/unsafe/foo.jpg", "c:/unsafe/foo.jpg"},
+		{"https://example.com/imagor/cd/unsafe/foo.jpg", "default:/cd/unsafe/foo.jpg"},
+		{"https://nil.example.com/imagor/unsafe/foo.jpg", "default:/unsafe/foo.jpg"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+		assert.Equal(t, test.expected, w.Body.String(), test.url)
+	}
+}
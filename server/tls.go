@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// certReloader holds the server certificate and swaps it in place on reload,
+// so that new handshakes pick up the renewed certificate without dropping
+// existing connections
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads certificate and key pair from files.
+// Existing certificate is kept if loading fails
+func (r *certReloader) reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// changed checks if certificate or key file modified since last reload
+func (r *certReloader) changed() bool {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !modTime.Equal(r.modTime)
+}
+
+func (r *certReloader) lastModified() (modTime time.Time, err error) {
+	for _, file := range []string{r.certFile, r.keyFile} {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return modTime, err
+		}
+		if stat.ModTime().After(modTime) {
+			modTime = stat.ModTime()
+		}
+	}
+	return
+}
+
+// GetCertificate implements tls.Config GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// watch reloads certificate on SIGHUP, or on file changes if interval is set
+func (r *certReloader) watch(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+		case <-tick:
+			if !r.changed() {
+				continue
+			}
+		}
+		if err := r.reload(); err != nil {
+			logger.Error("tls-reload", zap.Error(err))
+		} else {
+			logger.Info("tls-reload", zap.String("cert", r.certFile))
+		}
+	}
+}
+
+// newTLSConfig creates tls.Config with certificate reloader and optional client CA for mutual TLS,
+// cloned from base tls.Config if any, such that its settings e.g. cipher suites are retained
+func newTLSConfig(base *tls.Config, reloader *certReloader, clientCAFile string) (*tls.Config, error) {
+	var cfg *tls.Config
+	if base != nil {
+		cfg = base.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	cfg.GetCertificate = reloader.GetCertificate
+	if clientCAFile != "" {
+		buf, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return nil, errors.New("tls: no valid certificates in client CA file " + clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+func (s *Server) isTLS() bool {
+	return s.CertFile != "" && s.KeyFile != ""
+}
+
+// setupTLS configures server TLS and starts certificate reload watcher
+func (s *Server) setupTLS(ctx context.Context) error {
+	reloader, err := newCertReloader(s.CertFile, s.KeyFile)
+	if err != nil {
+		return err
+	}
+	cfg, err := newTLSConfig(s.TLSConfig, reloader, s.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	s.TLSConfig = cfg
+	go reloader.watch(ctx, s.TLSReloadInterval, s.Logger)
+	return nil
+}
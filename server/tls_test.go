@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cshum/imagor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	tls  tls.Certificate
+}
+
+func newTestCert(t *testing.T, serial int64, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "imagor"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	signerCert, signerKey := tmpl, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, pem: certPEM, tls: pair}
+}
+
+func writeTestCert(t *testing.T, dir string, c *testCert) (certFile, keyFile string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, c.pem, 0600))
+	require.NoError(t, os.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, newTestCert(t, 1, nil, false))
+
+	r, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), leaf.SerialNumber.Int64())
+	assert.False(t, r.changed())
+
+	writeTestCert(t, dir, newTestCert(t, 2, nil, false))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	assert.True(t, r.changed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.watch(ctx, time.Millisecond, zap.NewNop())
+	assert.Eventually(t, func() bool {
+		cert, _ := r.GetCertificate(nil)
+		leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+		return leaf.SerialNumber.Int64() == 2
+	}, time.Second, time.Millisecond)
+	assert.False(t, r.changed())
+
+	// broken certificate keeps the existing one
+	require.NoError(t, os.WriteFile(certFile, []byte("foo"), 0600))
+	assert.Error(t, r.reload())
+	cert, err = r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), leaf.SerialNumber.Int64())
+
+	_, err = newCertReloader(filepath.Join(dir, "missing.pem"), keyFile)
+	assert.Error(t, err)
+}
+
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, 1, nil, true)
+	certFile, keyFile := writeTestCert(t, dir, newTestCert(t, 2, ca, false))
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+	client := newTestCert(t, 3, ca, false)
+
+	s := New(imagor.New(),
+		WithTLS(certFile, keyFile),
+		WithTLSClientCA(caFile),
+		WithTLSReloadInterval(time.Second),
+	)
+	assert.True(t, s.isTLS())
+	assert.Equal(t, time.Second, s.TLSReloadInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, s.setupTLS(ctx))
+	assert.Equal(t, tls.RequireAndVerifyClientCert, s.TLSConfig.ClientAuth)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = s.ServeTLS(ln, "", "")
+	}()
+	defer s.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	url := "https://" + ln.Addr().String() + "/healthcheck"
+
+	c := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:      pool,
+			Certificates: []tls.Certificate{client.tls},
+		},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := c.Get(url)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	c = &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}}
+	_, err = c.Get(url)
+	assert.Error(t, err)
+}
+
+func TestServerTLSConfigRetained(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, newTestCert(t, 1, nil, false))
+
+	s := New(imagor.New(), WithTLS(certFile, keyFile))
+	base := &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		NextProtos:   []string{"h2"},
+	}
+	s.TLSConfig = base
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, s.setupTLS(ctx))
+	assert.NotSame(t, base, s.TLSConfig, "cloned")
+	assert.Nil(t, base.GetCertificate, "base not modified")
+	assert.NotNil(t, s.TLSConfig.GetCertificate)
+	assert.Equal(t, uint16(tls.VersionTLS13), s.TLSConfig.MinVersion)
+	assert.Equal(t, base.CipherSuites, s.TLSConfig.CipherSuites)
+	assert.Equal(t, []string{"h2"}, s.TLSConfig.NextProtos)
+
+	s = New(imagor.New(), WithTLS(certFile, keyFile))
+	require.NoError(t, s.setupTLS(ctx))
+	assert.Equal(t, uint16(tls.VersionTLS12), s.TLSConfig.MinVersion, "default")
+}
+
+func TestServerTLSInvalidClientCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, newTestCert(t, 1, nil, false))
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("foo"), 0600))
+
+	s := New(imagor.New(), WithTLS(certFile, keyFile), WithTLSClientCA(caFile))
+	assert.Error(t, s.setupTLS(context.Background()))
+}
@@ -0,0 +1,205 @@
+package azurestorage
+
+import (
+	"context"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	azureblob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagorpath"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AzureStorage Azure Blob Storage implements imagor.Storage interface
+type AzureStorage struct {
+	Client     *azblob.Client
+	Container  string
+	BaseDir    string
+	PathPrefix string
+	SafeChars  string
+	Expiration time.Duration
+
+	safeChars imagorpath.SafeChars
+}
+
+// New creates AzureStorage
+func New(client *azblob.Client, container string, options ...Option) *AzureStorage {
+	baseDir := "/"
+	if idx := strings.Index(container, "/"); idx > -1 {
+		baseDir = container[idx:]
+		container = container[:idx]
+	}
+	s := &AzureStorage{
+		Client:     client,
+		Container:  container,
+		BaseDir:    baseDir,
+		PathPrefix: "/",
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.safeChars = imagorpath.NewSafeChars(s.SafeChars)
+	return s
+}
+
+// Path transforms and validates image key for storage path
+func (s *AzureStorage) Path(image string) (string, bool) {
+	image = "/" + imagorpath.Normalize(image, s.safeChars)
+	if !strings.HasPrefix(image, s.PathPrefix) {
+		return "", false
+	}
+	joinedPath := filepath.Join(s.BaseDir, strings.TrimPrefix(image, s.PathPrefix))
+	// Azure blob names don't need to start with "/"
+	return strings.Trim(joinedPath, "/"), true
+}
+
+func (s *AzureStorage) blobClient(image string) *azureblob.Client {
+	return s.Client.ServiceClient().NewContainerClient(s.Container).NewBlobClient(image)
+}
+
+// Get implements imagor.Storage interface
+func (s *AzureStorage) Get(r *http.Request, image string) (*imagor.Blob, error) {
+	ctx := r.Context()
+	image, ok := s.Path(image)
+	if !ok {
+		return nil, imagor.ErrInvalid
+	}
+	var blob *imagor.Blob
+	var once sync.Once
+	blob = imagor.NewBlob(func() (io.ReadCloser, int64, error) {
+		out, err := s.blobClient(image).DownloadStream(ctx, nil)
+		if isNotFound(err) {
+			return nil, 0, imagor.ErrNotFound
+		} else if err != nil {
+			return nil, 0, err
+		}
+		once.Do(func() {
+			if out.ContentType != nil {
+				blob.SetContentType(*out.ContentType)
+			}
+			if out.ContentLength != nil && out.ETag != nil && out.LastModified != nil {
+				blob.Stat = &imagor.Stat{
+					Size:         *out.ContentLength,
+					ETag:         string(*out.ETag),
+					ModifiedTime: *out.LastModified,
+				}
+			}
+		})
+		if s.Expiration > 0 && out.LastModified != nil {
+			if time.Now().Sub(*out.LastModified) > s.Expiration {
+				_ = out.Body.Close()
+				return nil, 0, imagor.ErrExpired
+			}
+		}
+		var size int64
+		if out.ContentLength != nil {
+			size = *out.ContentLength
+		}
+		return out.Body, size, nil
+	})
+	return blob, nil
+}
+
+// Put implements imagor.Storage interface
+func (s *AzureStorage) Put(ctx context.Context, image string, blob *imagor.Blob) error {
+	image, ok := s.Path(image)
+	if !ok {
+		return imagor.ErrInvalid
+	}
+	reader, _, err := blob.NewReadSeeker()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	contentType := blob.ContentType()
+	_, err = s.Client.ServiceClient().NewContainerClient(s.Container).
+		NewBlockBlobClient(image).Upload(ctx, reader, &blockblob.UploadOptions{
+		HTTPHeaders: &azureblob.HTTPHeaders{
+			BlobContentType: &contentType,
+		},
+	})
+	return err
+}
+
+// Delete implements imagor.Storage interface
+func (s *AzureStorage) Delete(ctx context.Context, image string) error {
+	image, ok := s.Path(image)
+	if !ok {
+		return imagor.ErrInvalid
+	}
+	_, err := s.blobClient(image).Delete(ctx, nil)
+	if isNotFound(err) {
+		return imagor.ErrNotFound
+	}
+	return err
+}
+
+// DeletePrefix implements imagor.PrefixDeleter interface
+func (s *AzureStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	key, ok := s.Path(prefix)
+	if !ok {
+		return imagor.ErrInvalid
+	}
+	if strings.HasSuffix(prefix, "/") && key != "" {
+		key += "/"
+	}
+	pager := s.Client.NewListBlobsFlatPager(s.Container, &azblob.ListBlobsFlatOptions{
+		Prefix: &key,
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			if _, err := s.Client.DeleteBlob(ctx, s.Container, *item.Name, nil); err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stat implements imagor.Storage interface
+func (s *AzureStorage) Stat(ctx context.Context, image string) (*imagor.Stat, error) {
+	image, ok := s.Path(image)
+	if !ok {
+		return nil, imagor.ErrInvalid
+	}
+	props, err := s.blobClient(image).GetProperties(ctx, nil)
+	if isNotFound(err) {
+		return nil, imagor.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	stat := &imagor.Stat{}
+	if props.ContentLength != nil {
+		stat.Size = *props.ContentLength
+	}
+	if props.ETag != nil {
+		stat.ETag = string(*props.ETag)
+	}
+	if props.LastModified != nil {
+		stat.ModifiedTime = *props.LastModified
+	}
+	return stat, nil
+}
+
+func isNotFound(err error) bool {
+	return bloberror.HasCode(err,
+		bloberror.BlobNotFound, bloberror.ContainerNotFound, bloberror.ResourceNotFound)
+}
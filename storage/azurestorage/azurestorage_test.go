@@ -0,0 +1,336 @@
+package azurestorage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/cshum/imagor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAzureStore_Path(t *testing.T) {
+	tests := []struct {
+		name              string
+		container         string
+		baseDir           string
+		baseURI           string
+		image             string
+		safeChars         string
+		expectedPath      string
+		expectedContainer string
+		expectedOk        bool
+	}{
+		{
+			name:              "defaults ok",
+			container:         "mycontainer",
+			image:             "/foo/bar",
+			expectedContainer: "mycontainer",
+			expectedPath:      "foo/bar",
+			expectedOk:        true,
+		},
+		{
+			name:              "escape unsafe chars",
+			container:         "mycontainer",
+			image:             "/foo/b{:}ar",
+			expectedContainer: "mycontainer",
+			expectedPath:      "foo/b%7B%3A%7Dar",
+			expectedOk:        true,
+		},
+		{
+			name:              "escape safe chars",
+			container:         "mycontainer",
+			image:             "/foo/b{:}\"ar",
+			expectedContainer: "mycontainer",
+			expectedPath:      "foo/b{%3A}%22ar",
+			safeChars:         "{}",
+			expectedOk:        true,
+		},
+		{
+			name:              "path under with base uri",
+			container:         "mycontainer",
+			baseDir:           "/home/imagor",
+			baseURI:           "/foo",
+			image:             "/foo/bar",
+			expectedContainer: "mycontainer",
+			expectedPath:      "home/imagor/bar",
+			expectedOk:        true,
+		},
+		{
+			name:              "path under no base uri",
+			container:         "mycontainer",
+			baseDir:           "/home/imagor",
+			image:             "/foo/bar",
+			expectedContainer: "mycontainer",
+			expectedPath:      "home/imagor/foo/bar",
+			expectedOk:        true,
+		},
+		{
+			name:              "path not under",
+			container:         "mycontainer",
+			baseDir:           "/home/imagor",
+			baseURI:           "/foo",
+			image:             "/fooo/bar",
+			expectedContainer: "mycontainer",
+			expectedOk:        false,
+		},
+		{
+			name:              "extract container path under",
+			container:         "mycontainer/home/imagor",
+			baseURI:           "/foo",
+			image:             "/foo/bar",
+			expectedContainer: "mycontainer",
+			expectedPath:      "home/imagor/bar",
+			expectedOk:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.baseURI != "" {
+				opts = append(opts, WithPathPrefix(tt.baseURI))
+			}
+			if tt.baseDir != "" {
+				opts = append(opts, WithBaseDir(tt.baseDir))
+			}
+			opts = append(opts, WithSafeChars(tt.safeChars))
+			s := New(nil, tt.container, opts...)
+			res, ok := s.Path(tt.image)
+			if res != tt.expectedPath || ok != tt.expectedOk || s.Container != tt.expectedContainer {
+				t.Errorf("= %s,%s,%v want %s,%s,%v", s.Container, res, ok, tt.expectedContainer, tt.expectedPath, tt.expectedOk)
+			}
+		})
+	}
+}
+
+type fakeBlob struct {
+	data         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+}
+
+// fakeAzurite in-process stand-in of the Azurite emulator Blob REST API,
+// handling path style requests of /{account}/{container}/{blob}
+type fakeAzurite struct {
+	l     sync.Mutex
+	blobs map[string]*fakeBlob
+}
+
+func (f *fakeAzurite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(parts) == 2 && r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list" {
+		f.list(w, r, parts[1])
+		return
+	}
+	if len(parts) < 3 {
+		writeFakeError(w, r, http.StatusBadRequest, "InvalidUri")
+		return
+	}
+	key := parts[1] + "/" + parts[2]
+	f.l.Lock()
+	defer f.l.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		buf, _ := io.ReadAll(r.Body)
+		sum := md5.Sum(buf)
+		b := &fakeBlob{
+			data:         buf,
+			contentType:  r.Header.Get("x-ms-blob-content-type"),
+			etag:         `"0x` + strings.ToUpper(hex.EncodeToString(sum[:8])) + `"`,
+			lastModified: time.Now().UTC().Truncate(time.Second),
+		}
+		f.blobs[key] = b
+		w.Header().Set("ETag", b.etag)
+		w.Header().Set("Last-Modified", b.lastModified.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		b, ok := f.blobs[key]
+		if !ok {
+			writeFakeError(w, r, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(b.data)))
+		w.Header().Set("Content-Type", b.contentType)
+		w.Header().Set("ETag", b.etag)
+		w.Header().Set("Last-Modified", b.lastModified.Format(http.TimeFormat))
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(b.data)
+		}
+	case http.MethodDelete:
+		if _, ok := f.blobs[key]; !ok {
+			writeFakeError(w, r, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, key)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// fakeListPageSize small page size of blob listing for exercising pagination
+const fakeListPageSize = 2
+
+// list handles List Blobs of container, paginated by marker of blob name
+func (f *fakeAzurite) list(w http.ResponseWriter, r *http.Request, container string) {
+	var (
+		query  = r.URL.Query()
+		prefix = query.Get("prefix")
+		marker = query.Get("marker")
+		names  []string
+	)
+	f.l.Lock()
+	for key := range f.blobs {
+		if name := strings.TrimPrefix(key, container+"/"); name != key &&
+			strings.HasPrefix(name, prefix) && name >= marker {
+			names = append(names, name)
+		}
+	}
+	f.l.Unlock()
+	sort.Strings(names)
+	var nextMarker string
+	if len(names) > fakeListPageSize {
+		nextMarker = names[fakeListPageSize]
+		names = names[:fakeListPageSize]
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w,
+		`<?xml version="1.0" encoding="utf-8"?><EnumerationResults ServiceEndpoint="http://%s/" ContainerName="%s"><Prefix>%s</Prefix><Blobs>`,
+		r.Host, container, prefix)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(w, `<Blob><Name>%s</Name></Blob>`, name)
+	}
+	_, _ = fmt.Fprintf(w, `</Blobs><NextMarker>%s</NextMarker></EnumerationResults>`, nextMarker)
+}
+
+func writeFakeError(w http.ResponseWriter, r *http.Request, code int, errCode string) {
+	w.Header().Set("x-ms-error-code", errCode)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(code)
+	if r.Method != http.MethodHead {
+		_, _ = fmt.Fprintf(w,
+			`<?xml version="1.0" encoding="utf-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`,
+			errCode, errCode)
+	}
+}
+
+func fakeAzureClient(t *testing.T) *azblob.Client {
+	ts := httptest.NewServer(&fakeAzurite{blobs: map[string]*fakeBlob{}})
+	t.Cleanup(ts.Close)
+	client, err := azblob.NewClientWithNoCredential(ts.URL+"/devstoreaccount1", nil)
+	require.NoError(t, err)
+	return client
+}
+
+func TestCRUD(t *testing.T) {
+	var err error
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(fakeAzureClient(t), "test", WithPathPrefix("/foo"))
+
+	_, err = s.Get(r, "/bar/fooo/asdf")
+	assert.Equal(t, imagor.ErrInvalid, err)
+	_, err = s.Stat(ctx, "/bar/fooo/asdf")
+	assert.Equal(t, imagor.ErrInvalid, err)
+	assert.ErrorIs(t, s.Put(ctx, "/bar/fooo/asdf", imagor.NewBlobFromBytes([]byte("bar"))), imagor.ErrInvalid)
+	assert.Equal(t, imagor.ErrInvalid, s.Delete(ctx, "/bar/fooo/asdf"))
+
+	b, err := s.Get(r, "/foo/fooo/asdf")
+	require.NoError(t, err)
+	_, err = b.ReadAll()
+	assert.Equal(t, imagor.ErrNotFound, err)
+	_, err = s.Stat(ctx, "/foo/fooo/asdf")
+	assert.Equal(t, imagor.ErrNotFound, err)
+
+	blob := imagor.NewBlobFromBytes([]byte("bar"))
+	require.NoError(t, s.Put(ctx, "/foo/fooo/asdf", blob))
+
+	stat, err := s.Stat(ctx, "/foo/fooo/asdf")
+	require.NoError(t, err)
+	assert.True(t, stat.ModifiedTime.Before(time.Now()))
+	assert.NotEmpty(t, stat.ETag)
+	assert.Equal(t, int64(3), stat.Size)
+
+	b, err = s.Get(r, "/foo/fooo/asdf")
+	require.NoError(t, err)
+	buf, err := b.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+	assert.Equal(t, blob.ContentType(), b.ContentType())
+	require.NotEmpty(t, b.Stat)
+	assert.Equal(t, stat.ModifiedTime, b.Stat.ModifiedTime)
+	assert.Equal(t, stat.ETag, b.Stat.ETag)
+
+	require.NoError(t, s.Delete(ctx, "/foo/fooo/asdf"))
+	b, err = s.Get(r, "/foo/fooo/asdf")
+	require.NoError(t, err)
+	_, err = b.ReadAll()
+	assert.Equal(t, imagor.ErrNotFound, err)
+	assert.Equal(t, imagor.ErrNotFound, s.Delete(ctx, "/foo/fooo/asdf"))
+}
+
+func TestDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(fakeAzureClient(t), "test/base")
+	keys := []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg", "/pre/a.3.jpg", "/pre/ab.jpg", "/pre/b/a.jpg"}
+	for _, key := range keys {
+		require.NoError(t, s.Put(ctx, key, imagor.NewBlobFromBytes([]byte("bar"))))
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "/pre/a."))
+	for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg", "/pre/a.3.jpg"} {
+		b, _ := s.Get(r, key)
+		_, err := b.ReadAll()
+		assert.Equal(t, imagor.ErrNotFound, err, key)
+	}
+	for _, key := range []string{"/pre/ab.jpg", "/pre/b/a.jpg"} {
+		b, _ := s.Get(r, key)
+		_, err := b.ReadAll()
+		assert.NoError(t, err, key)
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "/pre/b/"))
+	b, _ := s.Get(r, "/pre/b/a.jpg")
+	_, err := b.ReadAll()
+	assert.Equal(t, imagor.ErrNotFound, err)
+	assert.NoError(t, s.DeletePrefix(ctx, "/nonexistent/a."))
+	assert.Equal(t, imagor.ErrInvalid,
+		New(nil, "test", WithPathPrefix("/foo")).DeletePrefix(ctx, "/bar/a."))
+}
+
+func TestExpiration(t *testing.T) {
+	var err error
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(fakeAzureClient(t), "test", WithExpiration(time.Second))
+
+	b, _ := s.Get(r, "/foo/bar/asdf")
+	_, err = b.ReadAll()
+	assert.Equal(t, imagor.ErrNotFound, err)
+	require.NoError(t, s.Put(ctx, "/foo/bar/asdf", imagor.NewBlobFromBytes([]byte("bar"))))
+
+	b, err = s.Get(r, "/foo/bar/asdf")
+	require.NoError(t, err)
+	buf, err := b.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+
+	time.Sleep(time.Second * 2)
+	b, _ = s.Get(r, "/foo/bar/asdf")
+	_, err = b.ReadAll()
+	require.ErrorIs(t, err, imagor.ErrExpired)
+}
@@ -112,7 +112,38 @@ func (s *FileStorage) Delete(_ context.Context, image string) error {
 	if !ok {
 		return imagor.ErrInvalid
 	}
-	return os.Remove(image)
+	if err := os.Remove(image); os.IsNotExist(err) {
+		return imagor.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix implements imagor.PrefixDeleter interface
+func (s *FileStorage) DeletePrefix(_ context.Context, prefix string) error {
+	dir, ok := s.Path(prefix)
+	if !ok {
+		return imagor.ErrInvalid
+	}
+	var name string
+	if !strings.HasSuffix(prefix, "/") {
+		dir, name = filepath.Split(dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), name) {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // Stat implements imagor.Storage interface
@@ -175,6 +175,28 @@ func TestFileStorage_Load_Save(t *testing.T) {
 		b, err = checkBlob(s.Get(r, "/foo/fooo/asdf"))
 		assert.Equal(t, imagor.ErrNotFound, err)
 
+		assert.Equal(t, imagor.ErrNotFound, s.Delete(ctx, "/foo/fooo/asdf"))
+	})
+
+	t.Run("delete prefix", func(t *testing.T) {
+		s := New(dir)
+		for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg", "/pre/ab.jpg", "/pre/a.b/c.jpg", "/pre/b/a.jpg"} {
+			require.NoError(t, s.Put(ctx, key, imagor.NewBlobFromBytes([]byte("bar"))))
+		}
+		require.NoError(t, s.DeletePrefix(ctx, "/pre/a."))
+		for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg", "/pre/a.b/c.jpg"} {
+			_, err := s.Stat(ctx, key)
+			assert.Equal(t, imagor.ErrNotFound, err, key)
+		}
+		for _, key := range []string{"/pre/ab.jpg", "/pre/b/a.jpg"} {
+			_, err := s.Stat(ctx, key)
+			assert.NoError(t, err, key)
+		}
+		require.NoError(t, s.DeletePrefix(ctx, "/pre/b/"))
+		_, err := s.Stat(ctx, "/pre/b/a.jpg")
+		assert.Equal(t, imagor.ErrNotFound, err)
+		assert.NoError(t, s.DeletePrefix(ctx, "/nonexistent/a."))
+		assert.Equal(t, imagor.ErrInvalid, s.DeletePrefix(ctx, "/.git/a."))
 	})
 
 	t.Run("save err if exists", func(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/cshum/imagor"
 	"github.com/cshum/imagor/imagorpath"
+	"google.golang.org/api/iterator"
 	"io"
 	"net/http"
 	"path/filepath"
@@ -106,7 +107,36 @@ func (s *GCloudStorage) Delete(ctx context.Context, image string) error {
 	if !ok {
 		return imagor.ErrInvalid
 	}
-	return s.client.Bucket(s.Bucket).Object(image).Delete(ctx)
+	err := s.client.Bucket(s.Bucket).Object(image).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return imagor.ErrNotFound
+	}
+	return err
+}
+
+// DeletePrefix implements imagor.PrefixDeleter interface
+func (s *GCloudStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	key, ok := s.Path(prefix)
+	if !ok {
+		return imagor.ErrInvalid
+	}
+	if strings.HasSuffix(prefix, "/") && key != "" {
+		key += "/"
+	}
+	bucket := s.client.Bucket(s.Bucket)
+	it := bucket.Objects(ctx, &storage.Query{Prefix: key})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := bucket.Object(attrs.Name).Delete(ctx); err != nil &&
+			!errors.Is(err, storage.ErrObjectNotExist) {
+			return err
+		}
+	}
 }
 
 // Path transforms and validates image key for storage path
@@ -145,6 +145,35 @@ func TestCRUD(t *testing.T) {
 	require.NoError(t, s.Put(ctx, "/foo/boo/asdf", imagor.NewBlobFromBytes([]byte("bar"))))
 }
 
+func TestDeletePrefix(t *testing.T) {
+	srv := fakestorage.NewServer([]fakestorage.Object{{
+		ObjectAttrs: fakestorage.ObjectAttrs{
+			BucketName: "test",
+			Name:       "placeholder",
+		},
+		Content: []byte(""),
+	}})
+	ctx := context.Background()
+	s := New(srv.Client(), "test", WithBaseDir("/base"))
+	for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg", "/pre/ab.jpg", "/pre/b/a.jpg"} {
+		require.NoError(t, s.Put(ctx, key, imagor.NewBlobFromBytes([]byte("bar"))))
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "/pre/a."))
+	for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg"} {
+		_, err := s.Stat(ctx, key)
+		assert.Equal(t, imagor.ErrNotFound, err, key)
+	}
+	for _, key := range []string{"/pre/ab.jpg", "/pre/b/a.jpg"} {
+		_, err := s.Stat(ctx, key)
+		assert.NoError(t, err, key)
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "/pre/b/"))
+	_, err := s.Stat(ctx, "/pre/b/a.jpg")
+	assert.Equal(t, imagor.ErrNotFound, err)
+	assert.Equal(t, imagor.ErrNotFound, s.Delete(ctx, "/pre/b/a.jpg"))
+	assert.NoError(t, s.DeletePrefix(ctx, "/nonexistent/a."))
+}
+
 func TestExpiration(t *testing.T) {
 	srv := fakestorage.NewServer([]fakestorage.Object{{
 		ObjectAttrs: fakestorage.ObjectAttrs{
@@ -0,0 +1,175 @@
+package memorystorage
+
+import (
+	"container/list"
+	"context"
+	"github.com/cshum/imagor"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryStorage in-memory LRU Storage implements imagor.Storage interface,
+// bounded by the total size of Blob bytes
+type MemoryStorage struct {
+	MaxSize int64
+
+	l      sync.Mutex
+	ll     *list.List
+	items  map[string]*list.Element
+	size   int64
+	hits   uint64
+	misses uint64
+}
+
+type entry struct {
+	key          string
+	buf          []byte
+	contentType  string
+	modifiedTime time.Time
+	etag         string
+}
+
+// New creates MemoryStorage with maximum size in bytes
+func New(maxSize int64) *MemoryStorage {
+	return &MemoryStorage{
+		MaxSize: maxSize,
+		ll:      list.New(),
+		items:   map[string]*list.Element{},
+	}
+}
+
+// Get implements imagor.Storage interface
+func (s *MemoryStorage) Get(_ *http.Request, key string) (*imagor.Blob, error) {
+	s.l.Lock()
+	el, ok := s.items[key]
+	if !ok {
+		s.l.Unlock()
+		atomic.AddUint64(&s.misses, 1)
+		return nil, imagor.ErrNotFound
+	}
+	s.ll.MoveToFront(el)
+	e := el.Value.(*entry)
+	s.l.Unlock()
+	atomic.AddUint64(&s.hits, 1)
+	blob := imagor.NewBlobFromBytes(e.buf)
+	if e.contentType != "" {
+		blob.SetContentType(e.contentType)
+	}
+	blob.Stat = &imagor.Stat{
+		Size:         int64(len(e.buf)),
+		ModifiedTime: e.modifiedTime,
+		ETag:         e.etag,
+	}
+	return blob, nil
+}
+
+// Put implements imagor.Storage interface.
+// Stat of Blob is kept if exists, such as read-through fill from upstream storage,
+// so that modified time and ETag stay consistent across storage tiers
+func (s *MemoryStorage) Put(_ context.Context, key string, blob *imagor.Blob) error {
+	buf, err := blob.ReadAll()
+	if err != nil {
+		return err
+	}
+	var modifiedTime = time.Now()
+	var etag string
+	if blob.Stat != nil {
+		if !blob.Stat.ModifiedTime.IsZero() {
+			modifiedTime = blob.Stat.ModifiedTime
+		}
+		etag = blob.Stat.ETag
+	}
+	size := int64(len(buf))
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.remove(key)
+	if size > s.MaxSize {
+		// skip blob that is larger than the whole cache
+		return nil
+	}
+	s.items[key] = s.ll.PushFront(&entry{
+		key:          key,
+		buf:          buf,
+		contentType:  blob.ContentType(),
+		modifiedTime: modifiedTime,
+		etag:         etag,
+	})
+	s.size += size
+	for s.size > s.MaxSize {
+		if el := s.ll.Back(); el != nil {
+			s.remove(el.Value.(*entry).key)
+		}
+	}
+	return nil
+}
+
+// Delete implements imagor.Storage interface
+func (s *MemoryStorage) Delete(_ context.Context, key string) error {
+	s.l.Lock()
+	s.remove(key)
+	s.l.Unlock()
+	return nil
+}
+
+// DeletePrefix implements imagor.PrefixDeleter interface
+func (s *MemoryStorage) DeletePrefix(_ context.Context, prefix string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	for key := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(key)
+		}
+	}
+	return nil
+}
+
+// Stat implements imagor.Storage interface
+func (s *MemoryStorage) Stat(_ context.Context, key string) (*imagor.Stat, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	el, ok := s.items[key]
+	if !ok {
+		return nil, imagor.ErrNotFound
+	}
+	e := el.Value.(*entry)
+	return &imagor.Stat{
+		Size:         int64(len(e.buf)),
+		ModifiedTime: e.modifiedTime,
+		ETag:         e.etag,
+	}, nil
+}
+
+// Size returns the total size of Blob bytes currently cached
+func (s *MemoryStorage) Size() int64 {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.size
+}
+
+// Len returns the number of items currently cached
+func (s *MemoryStorage) Len() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.ll.Len()
+}
+
+// Hits returns the number of Get cache hits
+func (s *MemoryStorage) Hits() uint64 {
+	return atomic.LoadUint64(&s.hits)
+}
+
+// Misses returns the number of Get cache misses
+func (s *MemoryStorage) Misses() uint64 {
+	return atomic.LoadUint64(&s.misses)
+}
+
+func (s *MemoryStorage) remove(key string) {
+	if el, ok := s.items[key]; ok {
+		s.ll.Remove(el)
+		delete(s.items, key)
+		s.size -= int64(len(el.Value.(*entry).buf))
+	}
+}
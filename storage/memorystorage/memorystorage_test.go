@@ -0,0 +1,136 @@
+package memorystorage
+
+import (
+	"context"
+	"github.com/cshum/imagor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(1024)
+
+	_, err := s.Get(r, "/foo/bar")
+	assert.Equal(t, imagor.ErrNotFound, err)
+	_, err = s.Stat(ctx, "/foo/bar")
+	assert.Equal(t, imagor.ErrNotFound, err)
+
+	blob := imagor.NewBlobFromBytes([]byte("bar"))
+	require.NoError(t, s.Put(ctx, "/foo/bar", blob))
+
+	stat, err := s.Stat(ctx, "/foo/bar")
+	require.NoError(t, err)
+	assert.True(t, stat.ModifiedTime.Before(time.Now()))
+	assert.Equal(t, int64(3), stat.Size)
+
+	b, err := s.Get(r, "/foo/bar")
+	require.NoError(t, err)
+	buf, err := b.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+	assert.Equal(t, blob.ContentType(), b.ContentType())
+	assert.Equal(t, stat, b.Stat)
+
+	require.NoError(t, s.Delete(ctx, "/foo/bar"))
+	_, err = s.Get(r, "/foo/bar")
+	assert.Equal(t, imagor.ErrNotFound, err)
+	assert.Zero(t, s.Size())
+	assert.Zero(t, s.Len())
+
+	assert.Equal(t, uint64(1), s.Hits())
+	assert.Equal(t, uint64(2), s.Misses())
+
+	// stat of upstream blob kept on read-through fill
+	modifiedTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	blob = imagor.NewBlobFromBytes([]byte("baz"))
+	blob.Stat = &imagor.Stat{ModifiedTime: modifiedTime, ETag: `"abc"`, Size: 3}
+	require.NoError(t, s.Put(ctx, "/foo/baz", blob))
+	stat, err = s.Stat(ctx, "/foo/baz")
+	require.NoError(t, err)
+	assert.Equal(t, &imagor.Stat{ModifiedTime: modifiedTime, ETag: `"abc"`, Size: 3}, stat)
+	b, err = s.Get(r, "/foo/baz")
+	require.NoError(t, err)
+	assert.Equal(t, stat, b.Stat)
+}
+
+func TestEviction(t *testing.T) {
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(10)
+
+	require.NoError(t, s.Put(ctx, "a", imagor.NewBlobFromBytes([]byte("aaaa"))))
+	require.NoError(t, s.Put(ctx, "b", imagor.NewBlobFromBytes([]byte("bbbb"))))
+	assert.Equal(t, int64(8), s.Size())
+
+	// touch a so that b becomes least recently used
+	_, err := s.Get(r, "a")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put(ctx, "c", imagor.NewBlobFromBytes([]byte("cccc"))))
+	assert.Equal(t, int64(8), s.Size())
+	assert.Equal(t, 2, s.Len())
+	_, err = s.Get(r, "b")
+	assert.Equal(t, imagor.ErrNotFound, err)
+	_, err = s.Get(r, "a")
+	assert.NoError(t, err)
+	_, err = s.Get(r, "c")
+	assert.NoError(t, err)
+
+	// replace existing key
+	require.NoError(t, s.Put(ctx, "c", imagor.NewBlobFromBytes([]byte("cc"))))
+	assert.Equal(t, int64(6), s.Size())
+
+	// skip blob larger than max size
+	require.NoError(t, s.Put(ctx, "d", imagor.NewBlobFromBytes([]byte("ddddddddddd"))))
+	_, err = s.Get(r, "d")
+	assert.Equal(t, imagor.ErrNotFound, err)
+	assert.Equal(t, int64(6), s.Size())
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	s := New(1000)
+	for _, key := range []string{"pre/a.1.jpg", "pre/a.2_10x10.jpg", "pre/ab.jpg"} {
+		require.NoError(t, s.Put(ctx, key, imagor.NewBlobFromBytes([]byte("bar"))))
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "pre/a."))
+	assert.Equal(t, 1, s.Len())
+	assert.Equal(t, int64(3), s.Size())
+	_, err := s.Stat(ctx, "pre/ab.jpg")
+	assert.NoError(t, err)
+}
+
+func TestReadThroughFill(t *testing.T) {
+	ctx := context.Background()
+	fast, slow := New(1024), New(1024)
+	blob := imagor.NewBlobFromBytes([]byte("bar"))
+	blob.Stat = &imagor.Stat{ModifiedTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Size: 3}
+	require.NoError(t, slow.Put(ctx, "foo", blob))
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithResultStorages(fast, slow),
+	)
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "bar", w.Body.String())
+		return w
+	}
+	w1 := do()
+	assert.Eventually(t, func() bool {
+		return fast.Len() == 1
+	}, time.Second, time.Millisecond)
+	w2 := do()
+	assert.Equal(t, uint64(1), fast.Hits())
+	assert.Equal(t, "Wed, 01 Jan 2020 00:00:00 GMT", w2.Header().Get("Last-Modified"))
+	assert.Equal(t, w1.Header().Get("Last-Modified"), w2.Header().Get("Last-Modified"))
+	assert.Equal(t, w1.Header().Get("ETag"), w2.Header().Get("ETag"))
+}
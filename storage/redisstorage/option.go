@@ -0,0 +1,26 @@
+package redisstorage
+
+import (
+	"time"
+)
+
+// Option RedisStorage option
+type Option func(s *RedisStorage)
+
+// WithPrefix with key prefix option
+func WithPrefix(prefix string) Option {
+	return func(s *RedisStorage) {
+		if prefix != "" {
+			s.Prefix = prefix
+		}
+	}
+}
+
+// WithExpiration with key TTL expiration option
+func WithExpiration(exp time.Duration) Option {
+	return func(s *RedisStorage) {
+		if exp > 0 {
+			s.Expiration = exp
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package redisstorage
+
+import (
+	"context"
+	"errors"
+	"github.com/cshum/imagor"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	fieldData         = "data"
+	fieldContentType  = "content_type"
+	fieldModifiedTime = "modified_time"
+)
+
+// RedisStorage Redis Storage implements imagor.Storage interface
+type RedisStorage struct {
+	Client     redis.UniversalClient
+	Prefix     string
+	Expiration time.Duration
+}
+
+// New creates RedisStorage
+func New(client redis.UniversalClient, options ...Option) *RedisStorage {
+	s := &RedisStorage{
+		Client: client,
+		Prefix: "imagor:",
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Key transforms image key for Redis key
+func (s *RedisStorage) Key(image string) string {
+	return s.Prefix + image
+}
+
+// Get implements imagor.Storage interface
+func (s *RedisStorage) Get(r *http.Request, image string) (*imagor.Blob, error) {
+	ctx := r.Context()
+	values, err := s.Client.HMGet(ctx, s.Key(image),
+		fieldData, fieldContentType, fieldModifiedTime).Result()
+	if err != nil {
+		return nil, err
+	}
+	data, ok := values[0].(string)
+	if !ok {
+		return nil, imagor.ErrNotFound
+	}
+	blob := imagor.NewBlobFromBytes([]byte(data))
+	if contentType, ok := values[1].(string); ok && contentType != "" {
+		blob.SetContentType(contentType)
+	}
+	if modTime, ok := parseModifiedTime(values[2]); ok {
+		blob.Stat = &imagor.Stat{
+			Size:         int64(len(data)),
+			ModifiedTime: modTime,
+		}
+	}
+	return blob, nil
+}
+
+// Put implements imagor.Storage interface
+func (s *RedisStorage) Put(ctx context.Context, image string, blob *imagor.Blob) error {
+	buf, err := blob.ReadAll()
+	if err != nil {
+		return err
+	}
+	key := s.Key(image)
+	_, err = s.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// replace existing key so that previous TTL does not carry over
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			fieldData, buf,
+			fieldContentType, blob.ContentType(),
+			fieldModifiedTime, time.Now().UnixNano(),
+		)
+		if s.Expiration > 0 {
+			pipe.Expire(ctx, key, s.Expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// Delete implements imagor.Storage interface
+func (s *RedisStorage) Delete(ctx context.Context, image string) error {
+	return s.Client.Del(ctx, s.Key(image)).Err()
+}
+
+// DeletePrefix implements imagor.PrefixDeleter interface, by SCAN and DEL of keys matching prefix,
+// on each master node for Redis Cluster
+func (s *RedisStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	var match = globEscaper.Replace(s.Key(prefix)) + "*"
+	if cluster, ok := s.Client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scanDelete(ctx, client, match)
+		})
+	}
+	return scanDelete(ctx, s.Client, match)
+}
+
+func scanDelete(ctx context.Context, client redis.Cmdable, match string) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			// delete keys individually as they may belong to different hash slots
+			if _, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range keys {
+					pipe.Del(ctx, key)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// globEscaper escapes glob-style pattern characters of Redis SCAN MATCH
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Stat implements imagor.Storage interface
+func (s *RedisStorage) Stat(ctx context.Context, image string) (*imagor.Stat, error) {
+	key := s.Key(image)
+	// typed HSTRLEN command, which go-redis has no method for
+	var size = redis.NewIntCmd(ctx, "hstrlen", key, fieldData)
+	var modTime *redis.StringCmd
+	if _, err := s.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		_ = pipe.Process(ctx, size)
+		modTime = pipe.HGet(ctx, key, fieldModifiedTime)
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	n := size.Val()
+	if errors.Is(modTime.Err(), redis.Nil) || n == 0 {
+		return nil, imagor.ErrNotFound
+	}
+	t, ok := parseModifiedTime(modTime.Val())
+	if !ok {
+		return nil, imagor.ErrNotFound
+	}
+	return &imagor.Stat{
+		Size:         n,
+		ModifiedTime: t,
+	}, nil
+}
+
+func parseModifiedTime(v any) (time.Time, bool) {
+	str, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ts), true
+}
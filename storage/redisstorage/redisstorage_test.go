@@ -0,0 +1,101 @@
+package redisstorage
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cshum/imagor"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func fakeRedisClient(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	mr := miniredis.RunT(t)
+	return mr, redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestCRUD(t *testing.T) {
+	mr, client := fakeRedisClient(t)
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(client, WithPrefix("foo:"))
+	assert.Equal(t, "foo:", s.Prefix)
+
+	_, err := s.Get(r, "/bar/fooo/asdf")
+	assert.Equal(t, imagor.ErrNotFound, err)
+
+	_, err = s.Stat(ctx, "/bar/fooo/asdf")
+	assert.Equal(t, imagor.ErrNotFound, err)
+
+	blob := imagor.NewBlobFromBytes([]byte("bar"))
+	require.NoError(t, s.Put(ctx, "/bar/fooo/asdf", blob))
+	assert.True(t, mr.Exists("foo:/bar/fooo/asdf"))
+
+	stat, err := s.Stat(ctx, "/bar/fooo/asdf")
+	require.NoError(t, err)
+	assert.True(t, stat.ModifiedTime.Before(time.Now()))
+	assert.Equal(t, int64(3), stat.Size)
+
+	b, err := s.Get(r, "/bar/fooo/asdf")
+	require.NoError(t, err)
+	buf, err := b.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+	assert.Equal(t, blob.ContentType(), b.ContentType())
+	require.NotEmpty(t, b.Stat)
+	assert.Equal(t, stat.ModifiedTime, b.Stat.ModifiedTime)
+	assert.Equal(t, stat.Size, b.Stat.Size)
+
+	require.NoError(t, s.Delete(ctx, "/bar/fooo/asdf"))
+	_, err = s.Get(r, "/bar/fooo/asdf")
+	assert.Equal(t, imagor.ErrNotFound, err)
+	_, err = s.Stat(ctx, "/bar/fooo/asdf")
+	assert.Equal(t, imagor.ErrNotFound, err)
+}
+
+func TestExpiration(t *testing.T) {
+	mr, client := fakeRedisClient(t)
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(client, WithExpiration(time.Second))
+	assert.Equal(t, "imagor:", s.Prefix)
+
+	require.NoError(t, s.Put(ctx, "/foo/bar/asdf", imagor.NewBlobFromBytes([]byte("bar"))))
+	assert.Equal(t, time.Second, mr.TTL("imagor:/foo/bar/asdf"))
+
+	b, err := s.Get(r, "/foo/bar/asdf")
+	require.NoError(t, err)
+	buf, err := b.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+
+	mr.FastForward(time.Second)
+	_, err = s.Get(r, "/foo/bar/asdf")
+	assert.Equal(t, imagor.ErrNotFound, err)
+
+	require.NoError(t, New(client).Put(ctx, "/foo/bar/asdf", imagor.NewBlobFromBytes([]byte("bar"))))
+	assert.Zero(t, mr.TTL("imagor:/foo/bar/asdf"))
+}
+
+func TestDeletePrefix(t *testing.T) {
+	mr, client := fakeRedisClient(t)
+	ctx := context.Background()
+	s := New(client)
+	for _, key := range []string{"pre/a.jpg", "pre/a.jpg/100x100", "pre/a.png", "pre/ab.jpg", "pre/a*.jpg", "other/a.jpg"} {
+		require.NoError(t, s.Put(ctx, key, imagor.NewBlobFromBytes([]byte("bar"))))
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "pre/a."))
+	for key, exists := range map[string]bool{
+		"pre/a.jpg": false, "pre/a.jpg/100x100": false, "pre/a.png": false,
+		"pre/ab.jpg": true, "pre/a*.jpg": true, "other/a.jpg": true,
+	} {
+		assert.Equal(t, exists, mr.Exists("imagor:"+key), key)
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "pre/a*"))
+	assert.False(t, mr.Exists("imagor:pre/a*.jpg"), "glob escaped")
+	assert.True(t, mr.Exists("imagor:pre/ab.jpg"), "glob escaped")
+	require.NoError(t, s.DeletePrefix(ctx, "nonexistent/"))
+}
@@ -2,6 +2,7 @@ package s3storage
 
 import (
 	"context"
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -152,6 +153,48 @@ func (s *S3Storage) Delete(ctx context.Context, image string) error {
 	return err
 }
 
+// DeletePrefix implements imagor.PrefixDeleter interface
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	key, ok := s.Path(prefix)
+	if !ok {
+		return imagor.ErrInvalid
+	}
+	if strings.HasSuffix(prefix, "/") {
+		key += "/"
+	}
+	// leading slash of object key is cleaned from request URI,
+	// which is not the case for list prefix
+	key = strings.TrimPrefix(key, "/")
+	var err error
+	if e := s.S3.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(key),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		if len(page.Contents) == 0 {
+			return true
+		}
+		objects := make([]*s3.ObjectIdentifier, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			objects = append(objects, &s3.ObjectIdentifier{Key: obj.Key})
+		}
+		var out *s3.DeleteObjectsOutput
+		out, err = s.S3.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.Bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err == nil && len(out.Errors) > 0 {
+			// per object failures are reported in output instead of error
+			e := out.Errors[0]
+			err = fmt.Errorf("s3storage: delete %d objects failed, %s: %s %s",
+				len(out.Errors), aws.StringValue(e.Key), aws.StringValue(e.Code), aws.StringValue(e.Message))
+		}
+		return err == nil
+	}); e != nil {
+		return e
+	}
+	return err
+}
+
 // Stat implements imagor.Storage interface
 func (s *S3Storage) Stat(ctx context.Context, image string) (stat *imagor.Stat, err error) {
 	image, ok := s.Path(image)
@@ -196,6 +196,58 @@ func TestCRUD(t *testing.T) {
 	require.NoError(t, s.Put(ctx, "/foo/boo/asdf", imagor.NewBlobFromBytes([]byte("bar"))))
 }
 
+func TestDeletePrefix(t *testing.T) {
+	ts := fakeS3Server()
+	defer ts.Close()
+
+	ctx := context.Background()
+	r := (&http.Request{}).WithContext(ctx)
+	s := New(fakeS3Session(ts, "test"), "test/base")
+	for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg", "/pre/ab.jpg", "/pre/b/a.jpg"} {
+		require.NoError(t, s.Put(ctx, key, imagor.NewBlobFromBytes([]byte("bar"))))
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "/pre/a."))
+	for _, key := range []string{"/pre/a.1.jpg", "/pre/a.2_10x10.jpg"} {
+		b, _ := s.Get(r, key)
+		_, err := b.ReadAll()
+		assert.Equal(t, imagor.ErrNotFound, err, key)
+	}
+	for _, key := range []string{"/pre/ab.jpg", "/pre/b/a.jpg"} {
+		b, _ := s.Get(r, key)
+		_, err := b.ReadAll()
+		assert.NoError(t, err, key)
+	}
+	require.NoError(t, s.DeletePrefix(ctx, "/pre/b/"))
+	b, _ := s.Get(r, "/pre/b/a.jpg")
+	_, err := b.ReadAll()
+	assert.Equal(t, imagor.ErrNotFound, err)
+	assert.NoError(t, s.DeletePrefix(ctx, "/nonexistent/a."))
+}
+
+func TestDeletePrefixObjectErrors(t *testing.T) {
+	backend := gofakes3.New(s3mem.New()).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["delete"]; ok && r.Method == http.MethodPost {
+			// per object failure comes with 200 OK
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+				`<DeleteResult><Error><Key>base/pre/a.1.jpg</Key><Code>AccessDenied</Code>` +
+				`<Message>Access Denied</Message></Error></DeleteResult>`))
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	s := New(fakeS3Session(ts, "test"), "test/base")
+	require.NoError(t, s.Put(ctx, "/pre/a.1.jpg", imagor.NewBlobFromBytes([]byte("bar"))))
+	err := s.DeletePrefix(ctx, "/pre/a.")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "base/pre/a.1.jpg")
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
 func TestExpiration(t *testing.T) {
 	ts := fakeS3Server()
 	defer ts.Close()
@@ -0,0 +1,63 @@
+package imagor
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer tracing hook that creates Span around imagor processing stages,
+// and propagates trace context across HTTP requests
+type Tracer interface {
+	// Start starts a Span of name, returns context that carries the Span
+	Start(ctx context.Context, name string) (context.Context, Span)
+
+	// Extract extracts trace context e.g. traceparent from incoming request header
+	Extract(ctx context.Context, header http.Header) context.Context
+
+	// Inject injects trace context e.g. traceparent into outgoing request header
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span represents a traced stage
+type Span interface {
+	// SetAttribute sets attribute of the Span
+	SetAttribute(key string, value any)
+
+	// End ends the Span, records error if not nil
+	End(err error)
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, any) {}
+
+func (nopSpan) End(error) {}
+
+// withTracer context with Tracer for StartSpan and InjectTrace
+func withTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerContextKey, tracer)
+}
+
+func contextTracer(ctx context.Context) Tracer {
+	if tracer, ok := ctx.Value(tracerContextKey).(Tracer); ok && tracer != nil {
+		return tracer
+	}
+	return nil
+}
+
+// StartSpan starts a Span from the imagor Tracer within context.
+// Returns no-op Span if Tracer not configured
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	if tracer := contextTracer(ctx); tracer != nil {
+		return tracer.Start(ctx, name)
+	}
+	return ctx, nopSpan{}
+}
+
+// InjectTrace injects trace context into outgoing request header
+// from the imagor Tracer within context
+func InjectTrace(ctx context.Context, header http.Header) {
+	if tracer := contextTracer(ctx); tracer != nil {
+		tracer.Inject(ctx, header)
+	}
+}
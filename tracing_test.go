@@ -0,0 +1,121 @@
+package imagor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cshum/imagor/imagorpath"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSpanKey struct{}
+
+type testTracer struct {
+	l     sync.Mutex
+	spans []string
+	errs  map[string]error
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	if parent, ok := ctx.Value(testSpanKey{}).(string); ok {
+		name = parent + ">" + name
+	}
+	return context.WithValue(ctx, testSpanKey{}, name), &testSpan{name: name, tracer: t}
+}
+
+func (t *testTracer) Extract(ctx context.Context, header http.Header) context.Context {
+	if parent := header.Get("traceparent"); parent != "" {
+		return context.WithValue(ctx, testSpanKey{}, parent)
+	}
+	return ctx
+}
+
+func (t *testTracer) Inject(ctx context.Context, header http.Header) {
+	if name, ok := ctx.Value(testSpanKey{}).(string); ok {
+		header.Set("traceparent", name)
+	}
+}
+
+func (t *testTracer) Names() []string {
+	t.l.Lock()
+	defer t.l.Unlock()
+	return append([]string{}, t.spans...)
+}
+
+type testSpan struct {
+	name   string
+	tracer *testTracer
+}
+
+func (s *testSpan) SetAttribute(string, any) {}
+
+func (s *testSpan) End(err error) {
+	s.tracer.l.Lock()
+	defer s.tracer.l.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s.name)
+	if err != nil {
+		s.tracer.errs[s.name] = err
+	}
+}
+
+func TestStartSpanNoTracer(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := StartSpan(ctx, "foo")
+	assert.Equal(t, ctx, spanCtx)
+	span.SetAttribute("foo", "bar")
+	span.End(nil)
+	header := http.Header{}
+	InjectTrace(ctx, header)
+	assert.Empty(t, header)
+}
+
+func TestWithTracer(t *testing.T) {
+	tracer := &testTracer{errs: map[string]error{}}
+	var injected string
+	resultStore := newMapStore()
+	app := New(
+		WithUnsafe(true),
+		WithTracer(tracer),
+		WithProcessConcurrency(1),
+		WithResultStorages(resultStore),
+		WithLoaders(loaderFunc(func(r *http.Request, image string) (*Blob, error) {
+			header := http.Header{}
+			InjectTrace(r.Context(), header)
+			injected = header.Get("traceparent")
+			return NewBlobFromBytes([]byte("foo")), nil
+		})),
+		WithProcessors(processorFunc(func(ctx context.Context, blob *Blob, p imagorpath.Params, load LoadFunc) (*Blob, error) {
+			_, span := StartSpan(ctx, "filter")
+			span.End(errors.New("boom"))
+			return blob, nil
+		})),
+	)
+	assert.Equal(t, tracer, app.Tracer)
+
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/unsafe/foo.jpg", nil)
+	r.Header.Set("traceparent", "parent")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "parent>imagor.Do>imagor.load", injected)
+
+	assert.Eventually(t, func() bool {
+		return len(tracer.Names()) == 7
+	}, time.Second, time.Millisecond)
+	assert.ElementsMatch(t, []string{
+		"parent>imagor.Do>imagor.load_result",
+		"parent>imagor.Do>imagor.acquire",
+		"parent>imagor.Do>imagor.load",
+		"parent>imagor.Do>imagor.process>filter",
+		"parent>imagor.Do>imagor.process",
+		"parent>imagor.Do>imagor.save",
+		"parent>imagor.Do",
+	}, tracer.Names())
+	assert.Error(t, tracer.errs["parent>imagor.Do>imagor.process>filter"])
+	assert.NoError(t, tracer.errs["parent>imagor.Do"])
+}
@@ -0,0 +1,109 @@
+package vips
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/imagemeta"
+	"github.com/cshum/imagor/imagorpath"
+)
+
+// analyzeSize maximum dimension of the downscaled image for analysis
+const analyzeSize = 64
+
+// analyze adds opt-in image analysis of meta filters to Metadata
+// e.g. phash(), dhash(), dominant_colors(n), blurhash(x,y), has_alpha() and icc_profile()
+func (v *Processor) analyze(ctx context.Context, img *Image, filters imagorpath.Filters, meta *Metadata) (err error) {
+	var (
+		phash, dhash bool
+		colorsN      int
+		blurX, blurY int
+	)
+	for _, filter := range filters {
+		if v.disableFilters[filter.Name] {
+			continue
+		}
+		switch filter.Name {
+		case "phash":
+			phash = true
+		case "dhash":
+			dhash = true
+		case "dominant_colors":
+			if colorsN, _ = strconv.Atoi(filter.Args); colorsN <= 0 {
+				colorsN = 5
+			} else if colorsN > 32 {
+				colorsN = 32
+			}
+		case "blurhash":
+			blurX, blurY = 4, 3
+			if args := strings.Split(filter.Args, ","); len(args) == 2 {
+				if x, _ := strconv.Atoi(args[0]); x > 0 {
+					blurX = x
+				}
+				if y, _ := strconv.Atoi(args[1]); y > 0 {
+					blurY = y
+				}
+			}
+		case "has_alpha":
+			hasAlpha := img.HasAlpha()
+			meta.HasAlpha = &hasAlpha
+		case "icc_profile":
+			meta.ICCProfile = imagemeta.ICCProfileName(img.ICCProfile())
+		}
+	}
+	if !phash && !dhash && colorsN == 0 && blurX == 0 {
+		return
+	}
+	ctx, span := imagor.StartSpan(ctx, "vips.analyze")
+	defer func() {
+		span.End(err)
+	}()
+	src, err := decodeThumbnail(img, analyzeSize)
+	if err != nil {
+		return
+	}
+	if phash {
+		meta.PHash = imagemeta.PHash(src)
+	}
+	if dhash {
+		meta.DHash = imagemeta.DHash(src)
+	}
+	if colorsN > 0 {
+		meta.DominantColors = imagemeta.DominantColors(src, colorsN)
+	}
+	if blurX > 0 {
+		meta.Blurhash = imagemeta.Blurhash(src, blurX, blurY)
+	}
+	return ctx.Err()
+}
+
+// decodeThumbnail decodes the first page of image downscaled to fit size as image.Image
+func decodeThumbnail(img *Image, size int) (image.Image, error) {
+	copied, err := img.Copy()
+	if err != nil {
+		return nil, err
+	}
+	defer copied.Close()
+	if copied.Height() > copied.PageHeight() {
+		if err = copied.ExtractArea(0, 0, copied.Width(), copied.PageHeight()); err != nil {
+			return nil, err
+		}
+	}
+	if err = copied.Thumbnail(size, size, InterestingNone); err != nil {
+		return nil, err
+	}
+	buf, err := copied.ExportPng(&PngExportParams{
+		StripMetadata: true,
+		Compression:   0,
+		Filter:        PngFilterNone,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(buf))
+}
@@ -0,0 +1,59 @@
+package vips
+
+import (
+	"context"
+	"image"
+
+	"github.com/cshum/imagor"
+	"go.uber.org/zap"
+)
+
+// Detector detects focal regions of image e.g. faces, feeding smart crop
+type Detector interface {
+	Detect(ctx context.Context, img image.Image) ([]image.Rectangle, error)
+}
+
+// detectFocalRects detects focal regions with Detector on a downscaled copy of image,
+// returns rectangles in coordinates of the image
+func (v *Processor) detectFocalRects(ctx context.Context, img *Image) (focalRects []focal) {
+	if v.Detector == nil || isAnimated(img) {
+		return
+	}
+	ctx, span := imagor.StartSpan(ctx, "vips.detect")
+	var err error
+	defer func() {
+		span.End(err)
+		if err != nil {
+			v.Logger.Warn("detect", zap.Error(err))
+		}
+	}()
+	src, err := decodeThumbnail(img, v.DetectSize)
+	if err != nil {
+		return
+	}
+	rects, err := v.Detector.Detect(ctx, src)
+	if err != nil {
+		return
+	}
+	var (
+		bounds = src.Bounds()
+		scaleX = float64(img.Width()) / float64(bounds.Dx())
+		scaleY = float64(img.PageHeight()) / float64(bounds.Dy())
+	)
+	for _, r := range rects {
+		r = r.Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+		focalRects = append(focalRects, focal{
+			Left:   float64(r.Min.X-bounds.Min.X) * scaleX,
+			Top:    float64(r.Min.Y-bounds.Min.Y) * scaleY,
+			Right:  float64(r.Max.X-bounds.Min.X) * scaleX,
+			Bottom: float64(r.Max.Y-bounds.Min.Y) * scaleY,
+		})
+	}
+	if v.Debug {
+		v.Logger.Debug("detect", zap.Any("rects", focalRects))
+	}
+	return
+}
@@ -3,9 +3,12 @@ package vips
 import (
 	"context"
 	"fmt"
+	"html"
 	"image/color"
 	"math"
 	"net/url"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -14,6 +17,25 @@ import (
 	"golang.org/x/image/colornames"
 )
 
+var svgVarRegex = regexp.MustCompile(`\{\{\s*[\w.-]+\s*\}\}`)
+
+var blendModeMap = map[string]BlendMode{
+	"over":        BlendModeOver,
+	"add":         BlendModeAdd,
+	"saturate":    BlendModeSaturate,
+	"multiply":    BlendModeMultiply,
+	"screen":      BlendModeScreen,
+	"overlay":     BlendModeOverlay,
+	"darken":      BlendModeDarken,
+	"lighten":     BlendModeLighten,
+	"color-dodge": BlendModeColorDodge,
+	"color-burn":  BlendModeColorBurn,
+	"hard-light":  BlendModeHardLight,
+	"soft-light":  BlendModeSoftLight,
+	"difference":  BlendModeDifference,
+	"exclusion":   BlendModeExclusion,
+}
+
 func (v *Processor) watermark(ctx context.Context, img *Image, load imagor.LoadFunc, args ...string) (err error) {
 	ln := len(args)
 	if ln < 1 {
@@ -32,7 +54,25 @@ func (v *Processor) watermark(ctx context.Context, img *Image, load imagor.LoadF
 	var down = 1
 	var overlay *Image
 	var n = 1
-	if isAnimated(img) {
+	var pos string
+	var rotation float64
+	var mode = BlendModeOver
+	if ln >= 3 {
+		pos = args[1]
+	}
+	// rotation
+	if ln >= 7 {
+		rotation, _ = strconv.ParseFloat(args[6], 64)
+	} else if pos == "diagonal" {
+		rotation = -45
+	}
+	// blend mode
+	if ln >= 8 {
+		if m, ok := blendModeMap[strings.ToLower(args[7])]; ok {
+			mode = m
+		}
+	}
+	if isAnimated(img) && rotation == 0 && pos != "diagonal" {
 		n = -1
 	}
 	// w_ratio h_ratio
@@ -69,8 +109,6 @@ func (v *Processor) watermark(ctx context.Context, img *Image, load imagor.LoadF
 	if err = overlay.AddAlpha(); err != nil {
 		return
 	}
-	w = overlay.Width()
-	h = overlay.PageHeight()
 	// alpha
 	if ln >= 4 {
 		alpha, _ := strconv.ParseFloat(args[3], 64)
@@ -81,49 +119,75 @@ func (v *Processor) watermark(ctx context.Context, img *Image, load imagor.LoadF
 			}
 		}
 	}
+	if rotation != 0 {
+		if err = overlay.RotateAngle(rotation); err != nil {
+			return
+		}
+	}
+	w = overlay.Width()
+	h = overlay.PageHeight()
 	// x y
 	if ln >= 3 {
-		if args[1] == "center" {
-			x = (img.Width() - overlay.Width()) / 2
-		} else if args[1] == imagorpath.HAlignLeft {
-			x = 0
-		} else if args[1] == imagorpath.HAlignRight {
-			x = img.Width() - overlay.Width()
-		} else if args[1] == "repeat" {
-			x = 0
-			across = img.Width()/overlay.Width() + 1
-		} else if strings.HasPrefix(strings.TrimPrefix(args[1], "-"), "0.") {
-			pec, _ := strconv.ParseFloat(args[1], 64)
-			x = int(pec * float64(img.Width()))
-		} else if strings.HasSuffix(args[1], "p") {
-			x, _ = strconv.Atoi(strings.TrimSuffix(args[1], "p"))
-			x = x * img.Width() / 100
-		} else {
-			x, _ = strconv.Atoi(args[1])
-		}
-		if args[2] == "center" {
-			y = (img.PageHeight() - overlay.PageHeight()) / 2
-		} else if args[2] == imagorpath.VAlignTop {
-			y = 0
-		} else if args[2] == imagorpath.VAlignBottom {
-			y = img.PageHeight() - overlay.PageHeight()
-		} else if args[2] == "repeat" {
-			y = 0
-			down = img.PageHeight()/overlay.PageHeight() + 1
-		} else if strings.HasPrefix(strings.TrimPrefix(args[2], "-"), "0.") {
-			pec, _ := strconv.ParseFloat(args[2], 64)
-			y = int(pec * float64(img.PageHeight()))
-		} else if strings.HasSuffix(args[2], "p") {
-			y, _ = strconv.Atoi(strings.TrimSuffix(args[2], "p"))
-			y = y * img.PageHeight() / 100
-		} else {
-			y, _ = strconv.Atoi(args[2])
-		}
-		if x < 0 {
-			x += img.Width() - overlay.Width()
-		}
-		if y < 0 {
-			y += img.PageHeight() - overlay.PageHeight()
+		switch pos {
+		case "top-left", "top", "top-right", "bottom-left", "bottom", "bottom-right":
+			// position keyword with margin
+			x, y = watermarkPosition(pos, img.Width(), img.PageHeight(), w, h,
+				watermarkMargin(args[2], img.Width(), img.PageHeight()))
+		case "auto":
+			// corner with the lowest detail and margin
+			if x, y, err = lowestDetailCorner(img, w, h,
+				watermarkMargin(args[2], img.Width(), img.PageHeight())); err != nil {
+				return
+			}
+		case "diagonal":
+			// diagonal tiles with gap
+			if err = diagonalTile(overlay, img.Width(), img.PageHeight(),
+				watermarkMargin(args[2], img.Width(), img.PageHeight())); err != nil {
+				return
+			}
+		default:
+			if args[1] == "center" {
+				x = (img.Width() - overlay.Width()) / 2
+			} else if args[1] == imagorpath.HAlignLeft {
+				x = 0
+			} else if args[1] == imagorpath.HAlignRight {
+				x = img.Width() - overlay.Width()
+			} else if args[1] == "repeat" {
+				x = 0
+				across = img.Width()/overlay.Width() + 1
+			} else if strings.HasPrefix(strings.TrimPrefix(args[1], "-"), "0.") {
+				pec, _ := strconv.ParseFloat(args[1], 64)
+				x = int(pec * float64(img.Width()))
+			} else if strings.HasSuffix(args[1], "p") {
+				x, _ = strconv.Atoi(strings.TrimSuffix(args[1], "p"))
+				x = x * img.Width() / 100
+			} else {
+				x, _ = strconv.Atoi(args[1])
+			}
+			if args[2] == "center" {
+				y = (img.PageHeight() - overlay.PageHeight()) / 2
+			} else if args[2] == imagorpath.VAlignTop {
+				y = 0
+			} else if args[2] == imagorpath.VAlignBottom {
+				y = img.PageHeight() - overlay.PageHeight()
+			} else if args[2] == "repeat" {
+				y = 0
+				down = img.PageHeight()/overlay.PageHeight() + 1
+			} else if strings.HasPrefix(strings.TrimPrefix(args[2], "-"), "0.") {
+				pec, _ := strconv.ParseFloat(args[2], 64)
+				y = int(pec * float64(img.PageHeight()))
+			} else if strings.HasSuffix(args[2], "p") {
+				y, _ = strconv.Atoi(strings.TrimSuffix(args[2], "p"))
+				y = y * img.PageHeight() / 100
+			} else {
+				y, _ = strconv.Atoi(args[2])
+			}
+			if x < 0 {
+				x += img.Width() - overlay.Width()
+			}
+			if y < 0 {
+				y += img.PageHeight() - overlay.PageHeight()
+			}
 		}
 	}
 	if across*down > 1 {
@@ -145,12 +209,96 @@ func (v *Processor) watermark(ctx context.Context, img *Image, load imagor.LoadF
 			return
 		}
 	}
-	if err = img.Composite(overlay, BlendModeOver, 0, 0); err != nil {
+	if err = img.Composite(overlay, mode, 0, 0); err != nil {
 		return
 	}
 	return
 }
 
+// watermarkMargin parses margin in percentage of the shorter side of image,
+// such that margins are consistent across aspect ratios
+func watermarkMargin(arg string, width, height int) int {
+	pec, _ := strconv.ParseFloat(strings.TrimSuffix(arg, "p"), 64)
+	if pec <= 0 {
+		return 0
+	}
+	if height < width {
+		width = height
+	}
+	return int(pec * float64(width) / 100)
+}
+
+// watermarkPosition returns overlay position of position keyword
+// e.g. top-left, bottom, bottom-right with margin
+func watermarkPosition(pos string, width, height, w, h, margin int) (x, y int) {
+	x = (width - w) / 2
+	if strings.HasSuffix(pos, "left") {
+		x = margin
+	} else if strings.HasSuffix(pos, "right") {
+		x = width - w - margin
+	}
+	y = margin
+	if strings.HasPrefix(pos, "bottom") {
+		y = height - h - margin
+	}
+	return
+}
+
+// lowestDetailCorner returns overlay position of the image corner
+// with the lowest detail, measured by standard deviation of the area covered
+func lowestDetailCorner(img *Image, w, h, margin int) (x, y int, err error) {
+	var minDeviate = math.MaxFloat64
+	for _, pos := range []string{"bottom-right", "bottom-left", "top-right", "top-left"} {
+		cx, cy := watermarkPosition(pos, img.Width(), img.PageHeight(), w, h, margin)
+		var deviate float64
+		if deviate, err = areaDeviate(img, cx, cy, w, h); err != nil {
+			return
+		}
+		if deviate < minDeviate {
+			minDeviate = deviate
+			x, y = cx, cy
+		}
+	}
+	return
+}
+
+func areaDeviate(img *Image, left, top, width, height int) (float64, error) {
+	right := int(math.Min(float64(left+width), float64(img.Width())))
+	bottom := int(math.Min(float64(top+height), float64(img.PageHeight())))
+	left = int(math.Max(float64(left), 0))
+	top = int(math.Max(float64(top), 0))
+	if right <= left || bottom <= top {
+		return 0, nil
+	}
+	area, err := img.Copy()
+	if err != nil {
+		return 0, err
+	}
+	defer area.Close()
+	if err = area.ExtractArea(left, top, right-left, bottom-top); err != nil {
+		return 0, err
+	}
+	return area.Deviate()
+}
+
+// diagonalTile tiles overlay in staggered rows with gap, forming diagonal lines
+func diagonalTile(overlay *Image, width, height, gap int) error {
+	cellW := overlay.Width() + gap
+	cellH := overlay.PageHeight() + gap
+	cell, err := overlay.Copy()
+	if err != nil {
+		return err
+	}
+	defer cell.Close()
+	if err = overlay.EmbedBackgroundRGBA(0, 0, cellW*2, cellH*2, &ColorRGBA{}); err != nil {
+		return err
+	}
+	if err = overlay.Composite(cell, BlendModeOver, cellW, cellH); err != nil {
+		return err
+	}
+	return overlay.Embed(0, 0, width, height, ExtendRepeat)
+}
+
 func setFrames(_ context.Context, img *Image, _ imagor.LoadFunc, args ...string) (err error) {
 	ln := len(args)
 	if ln == 0 {
@@ -189,6 +337,98 @@ func setFrames(_ context.Context, img *Image, _ imagor.LoadFunc, args ...string)
 	return
 }
 
+func (v *Processor) frames(ctx context.Context, img *Image, load imagor.LoadFunc, args ...string) (err error) {
+	ln := len(args)
+	if ln == 0 {
+		return
+	}
+	var delay int
+	if i := strings.LastIndex(args[ln-1], ";"); i > -1 {
+		delay, _ = strconv.Atoi(args[ln-1][i+1:])
+		if args[ln-1] = args[ln-1][:i]; args[ln-1] == "" {
+			args = args[:ln-1]
+		}
+	}
+	if delay <= 0 {
+		delay = 100
+	}
+	var maxN = v.MaxAnimationFrames
+	if maxN == 0 || maxN < -1 {
+		maxN = 1
+	} else if maxN == -1 || maxN > maxFrames {
+		// each frame is held in memory until joined, hence always bounded
+		maxN = maxFrames
+	}
+	var width = img.Width()
+	var height = img.PageHeight()
+	var n = img.Height() / height
+	if err = rgba(img); err != nil {
+		return
+	}
+	var frames []*Image
+	for _, arg := range args {
+		if n+len(frames) >= maxN {
+			break
+		}
+		if v.MaxResolution > 0 && width*height*(n+len(frames)+1) > v.MaxResolution {
+			// joined image of all frames exceeding max resolution
+			return imagor.ErrMaxResolutionExceeded
+		}
+		image := arg
+		if unescape, e := url.QueryUnescape(arg); e == nil {
+			image = unescape
+		}
+		var blob *imagor.Blob
+		if blob, err = load(image); err != nil {
+			return
+		}
+		var frame *Image
+		if frame, err = v.NewThumbnail(
+			ctx, blob, width, height, InterestingCentre, SizeBoth, 1, 1, 0,
+		); err != nil {
+			return
+		}
+		contextDefer(ctx, frame.Close)
+		if frame.Width() != width || frame.Height() != height {
+			if err = frame.Thumbnail(width, height, InterestingCentre); err != nil {
+				return
+			}
+		}
+		if err = rgba(frame); err != nil {
+			return
+		}
+		frames = append(frames, frame)
+	}
+	if len(frames) == 0 {
+		return
+	}
+	if err = img.ArrayJoin(frames, 1); err != nil {
+		return
+	}
+	if err = img.SetPageHeight(height); err != nil {
+		return
+	}
+	n += len(frames)
+	delays := make([]int, n)
+	for i := 0; i < n; i++ {
+		delays[i] = delay
+	}
+	return img.SetPageDelay(delays)
+}
+
+// maxFrames hard limit of number of frames built by frames filter
+const maxFrames = 100
+
+// rgba makes sure image is sRGB with alpha channel
+func rgba(img *Image) (err error) {
+	if img.Bands() < 3 {
+		if err = img.ToColorSpace(InterpretationSRGB); err != nil {
+			return
+		}
+	}
+	return img.AddAlpha()
+}
+
 func (v *Processor) fill(ctx context.Context, img *Image, w, h int, pLeft, pTop, pRight, pBottom int, colour string) (err error) {
 	if isRotate90(ctx) {
 		tmpW := w
@@ -387,6 +627,343 @@ func label(_ context.Context, img *Image, _ imagor.LoadFunc, args ...string) (er
 	return img.Label(text, font, x, y, size, align, c, 1-alpha)
 }
 
+// limits of text filter, bounding the cost of text rendering
+const (
+	maxTextSize         = 500
+	maxTextStroke       = 20
+	maxTextShadowOffset = 100
+	maxTextShadowBlur   = 20
+	maxTextPadding      = 200
+)
+
+// clampInt clamps n within min and max
+func clampInt(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+func drawText(ctx context.Context, img *Image, load imagor.LoadFunc, args ...string) (err error) {
+	ln := len(args)
+	if ln == 0 {
+		return
+	}
+	for i := range args {
+		if a, e := url.QueryUnescape(args[i]); e == nil {
+			args[i] = a
+		}
+	}
+	var text = args[0]
+	if text == "" {
+		return
+	}
+	var font = "sans"
+	var fontFile string
+	var size = 20
+	var c = &Color{}
+	var width, spacing int
+	var align = AlignLow
+	var strokeWidth int
+	var strokeColor = &Color{}
+	var shadowX, shadowY int
+	var shadowBlur float64
+	var shadowColor = &Color{}
+	var shadowAlpha float64
+	var hasShadow bool
+	var padding = -1
+	var bgColor = &Color{}
+	var bgAlpha float64
+	// font, loaded as font file if TTF or OTF
+	if ln > 3 && args[3] != "" {
+		if isFontFile(args[3]) {
+			var blob *imagor.Blob
+			if blob, err = load(args[3]); err != nil {
+				return
+			}
+			var face fontFace
+			if face, err = loadFontFace(blob, filepath.Ext(args[3])); err != nil {
+				return
+			}
+			font = face.Family
+			fontFile = face.Path
+		} else {
+			font = args[3]
+		}
+	}
+	if ln > 4 {
+		if n, _ := strconv.Atoi(args[4]); n > 0 {
+			size = clampInt(n, 1, maxTextSize)
+		}
+	}
+	if ln > 5 && args[5] != "" {
+		c = getColor(img, args[5])
+	}
+	// wrap width
+	if ln > 6 {
+		if strings.HasSuffix(args[6], "p") {
+			width, _ = strconv.Atoi(strings.TrimSuffix(args[6], "p"))
+			width = width * img.Width() / 100
+		} else {
+			width, _ = strconv.Atoi(args[6])
+		}
+	}
+	if ln > 7 {
+		if args[7] == "center" {
+			align = AlignCenter
+		} else if args[7] == imagorpath.HAlignRight {
+			align = AlignHigh
+		}
+	}
+	if ln > 8 {
+		spacing, _ = strconv.Atoi(args[8])
+	}
+	// stroke width:color
+	if ln > 9 && args[9] != "" {
+		parts := strings.Split(args[9], ":")
+		strokeWidth, _ = strconv.Atoi(parts[0])
+		strokeWidth = clampInt(strokeWidth, 0, maxTextStroke)
+		if len(parts) > 1 {
+			strokeColor = getColor(img, parts[1])
+		}
+	}
+	// shadow x:y:blur:color:alpha
+	if ln > 10 && args[10] != "" {
+		parts := strings.Split(args[10], ":")
+		hasShadow = true
+		shadowX, _ = strconv.Atoi(parts[0])
+		shadowX = clampInt(shadowX, -maxTextShadowOffset, maxTextShadowOffset)
+		if len(parts) > 1 {
+			shadowY, _ = strconv.Atoi(parts[1])
+			shadowY = clampInt(shadowY, -maxTextShadowOffset, maxTextShadowOffset)
+		}
+		if len(parts) > 2 {
+			shadowBlur, _ = strconv.ParseFloat(parts[2], 64)
+			if !(shadowBlur > 0) {
+				shadowBlur = 0 // negative or NaN
+			} else if shadowBlur > maxTextShadowBlur {
+				shadowBlur = maxTextShadowBlur
+			}
+		}
+		if len(parts) > 3 {
+			shadowColor = getColor(img, parts[3])
+		}
+		if len(parts) > 4 {
+			shadowAlpha, _ = strconv.ParseFloat(parts[4], 64)
+			shadowAlpha /= 100
+		}
+	}
+	// background padding:color:alpha
+	if ln > 11 && args[11] != "" {
+		parts := strings.Split(args[11], ":")
+		padding, _ = strconv.Atoi(parts[0])
+		padding = clampInt(padding, 0, maxTextPadding)
+		if len(parts) > 1 {
+			bgColor = getColor(img, parts[1])
+		}
+		if len(parts) > 2 {
+			bgAlpha, _ = strconv.ParseFloat(parts[2], 64)
+			bgAlpha /= 100
+		}
+	}
+	mask, err := NewText(html.EscapeString(text), font+" "+strconv.Itoa(size), fontFile, width, spacing, align)
+	if err != nil {
+		return
+	}
+	contextDefer(ctx, mask.Close)
+	var w, h = mask.Width(), mask.Height()
+	var pad = int(math.Max(float64(padding), 0))
+	// margin of canvas that fits background, stroke and shadow
+	var margin = pad + strokeWidth
+	if hasShadow {
+		margin += int(math.Max(math.Abs(float64(shadowX)), math.Abs(float64(shadowY))) + math.Ceil(shadowBlur*3))
+	}
+	var cw, ch = w + margin*2, h + margin*2
+	if err = mask.Embed(margin, margin, cw, ch, ExtendBlack); err != nil {
+		return
+	}
+	var layers []*Image
+	if padding >= 0 {
+		var box *Image
+		if box, err = mask.Copy(); err != nil {
+			return
+		}
+		contextDefer(ctx, box.Close)
+		layers = append(layers, box)
+		if err = box.ExtractArea(margin-pad, margin-pad, w+pad*2, h+pad*2); err != nil {
+			return
+		}
+		if err = box.Linear([]float64{0}, []float64{255}); err != nil {
+			return
+		}
+		if err = box.Embed(margin-pad, margin-pad, cw, ch, ExtendBlack); err != nil {
+			return
+		}
+		if err = box.MaskRGBA(bgColor, 1-bgAlpha); err != nil {
+			return
+		}
+	}
+	var outline *Image
+	if outline, err = mask.Copy(); err != nil {
+		return
+	}
+	contextDefer(ctx, outline.Close)
+	if strokeWidth > 0 {
+		// dilate text mask by stroke width
+		var n = strokeWidth*2 + 1
+		if err = outline.Rank(n, n, n*n-1); err != nil {
+			return
+		}
+	}
+	if hasShadow {
+		var shadow *Image
+		if shadow, err = outline.Copy(); err != nil {
+			return
+		}
+		contextDefer(ctx, shadow.Close)
+		layers = append(layers, shadow)
+		if err = shadow.Embed(shadowX, shadowY, cw, ch, ExtendBlack); err != nil {
+			return
+		}
+		if shadowBlur > 0 {
+			if err = shadow.GaussianBlur(shadowBlur); err != nil {
+				return
+			}
+		}
+		if err = shadow.MaskRGBA(shadowColor, 1-shadowAlpha); err != nil {
+			return
+		}
+	}
+	if strokeWidth > 0 {
+		var stroke *Image
+		if stroke, err = outline.Copy(); err != nil {
+			return
+		}
+		contextDefer(ctx, stroke.Close)
+		layers = append(layers, stroke)
+		if err = stroke.MaskRGBA(strokeColor, 1); err != nil {
+			return
+		}
+	}
+	if err = mask.MaskRGBA(c, 1); err != nil {
+		return
+	}
+	// composite layers bottom up: background, shadow, stroke, text
+	layers = append(layers, mask)
+	var overlay = layers[0]
+	for _, layer := range layers[1:] {
+		if err = overlay.Composite(layer, BlendModeOver, 0, 0); err != nil {
+			return
+		}
+	}
+	var x, y int
+	if ln > 1 {
+		x = overlayOffset(args[1], img.Width(), w+pad*2)
+	}
+	if ln > 2 {
+		y = overlayOffset(args[2], img.PageHeight(), h+pad*2)
+	}
+	return compositeOverlay(img, overlay, x-margin+pad, y-margin+pad)
+}
+
+func (v *Processor) overlaySVG(ctx context.Context, img *Image, load imagor.LoadFunc, args ...string) (err error) {
+	ln := len(args)
+	if ln == 0 {
+		return
+	}
+	for i := range args {
+		if a, e := url.QueryUnescape(args[i]); e == nil {
+			args[i] = a
+		}
+	}
+	var blob *imagor.Blob
+	if blob, err = load(args[0]); err != nil {
+		return
+	}
+	var buf []byte
+	if buf, err = blob.ReadAll(); err != nil {
+		return
+	}
+	var vars = map[string]string{}
+	if ln > 3 {
+		for _, arg := range args[3:] {
+			if k, val, ok := strings.Cut(arg, "="); ok {
+				vars[strings.TrimSpace(k)] = val
+			}
+		}
+	}
+	buf = svgVarRegex.ReplaceAllFunc(buf, func(b []byte) []byte {
+		name := strings.TrimSpace(string(b[2 : len(b)-2]))
+		return []byte(html.EscapeString(vars[name]))
+	})
+	var overlay *Image
+	if overlay, err = v.NewImage(ctx, imagor.NewBlobFromBytes(buf), 1, 1, 0); err != nil {
+		return
+	}
+	contextDefer(ctx, overlay.Close)
+	if overlay.Bands() < 3 {
+		if err = overlay.ToColorSpace(InterpretationSRGB); err != nil {
+			return
+		}
+	}
+	if err = overlay.AddAlpha(); err != nil {
+		return
+	}
+	var x, y int
+	if ln > 1 {
+		x = overlayOffset(args[1], img.Width(), overlay.Width())
+	}
+	if ln > 2 {
+		y = overlayOffset(args[2], img.PageHeight(), overlay.PageHeight())
+	}
+	return compositeOverlay(img, overlay, x, y)
+}
+
+// overlayOffset parses x or y offset of overlay relative to image size,
+// in pixels, percentage, float ratio, or alignment keyword
+func overlayOffset(arg string, size, length int) (n int) {
+	switch arg {
+	case "center":
+		return (size - length) / 2
+	case imagorpath.HAlignLeft, imagorpath.VAlignTop:
+		return 0
+	case imagorpath.HAlignRight, imagorpath.VAlignBottom:
+		return size - length
+	}
+	if strings.HasPrefix(strings.TrimPrefix(arg, "-"), "0.") {
+		pec, _ := strconv.ParseFloat(arg, 64)
+		n = int(pec * float64(size))
+	} else if strings.HasSuffix(arg, "p") {
+		n, _ = strconv.Atoi(strings.TrimSuffix(arg, "p"))
+		n = n * size / 100
+	} else {
+		n, _ = strconv.Atoi(arg)
+	}
+	if n < 0 {
+		n += size - length
+	}
+	return
+}
+
+// compositeOverlay composites single page overlay on top of image at position,
+// replicated across frames of animated image
+func compositeOverlay(img *Image, overlay *Image, x, y int) (err error) {
+	if err = overlay.EmbedBackgroundRGBA(
+		x, y, img.Width(), img.PageHeight(), &ColorRGBA{},
+	); err != nil {
+		return
+	}
+	if n := img.Height() / img.PageHeight(); n > 1 {
+		if err = overlay.Replicate(1, n); err != nil {
+			return
+		}
+	}
+	return img.Composite(overlay, BlendModeOver, 0, 0)
+}
+
 func (v *Processor) padding(ctx context.Context, img *Image, _ imagor.LoadFunc, args ...string) error {
 	ln := len(args)
 	if ln < 2 {
@@ -0,0 +1,96 @@
+package vips
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cshum/imagor"
+	"golang.org/x/image/font/sfnt"
+)
+
+// maxFontFaces maximum number of font files cached in temp directory
+const maxFontFaces = 100
+
+type fontFace struct {
+	Path   string
+	Family string
+}
+
+// fontFaces cache of font faces keyed by content hash,
+// evicted in insertion order with font files removed
+var fontFaces = struct {
+	sync.Mutex
+	dir   string
+	faces map[string]fontFace
+	keys  []string
+}{faces: map[string]fontFace{}}
+
+// isFontFile checks if name refers to a TrueType or OpenType font file
+func isFontFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ttf", ".otf":
+		return true
+	}
+	return false
+}
+
+// loadFontFace writes font blob to temp directory keyed by content hash,
+// such that it can be referenced as font file for text rendering,
+// returns the font file path and font family name
+func loadFontFace(blob *imagor.Blob, ext string) (face fontFace, err error) {
+	buf, err := blob.ReadAll()
+	if err != nil {
+		return
+	}
+	sum := sha1.Sum(buf)
+	key := hex.EncodeToString(sum[:])
+	fontFaces.Lock()
+	defer fontFaces.Unlock()
+	if face, ok := fontFaces.faces[key]; ok {
+		return face, nil
+	}
+	f, err := sfnt.Parse(buf)
+	if err != nil {
+		return face, imagor.NewError(fmt.Sprintf("invalid font: %s", err), http.StatusNotAcceptable)
+	}
+	if face.Family, err = f.Name(nil, sfnt.NameIDFamily); err != nil {
+		return face, imagor.NewError(fmt.Sprintf("invalid font: %s", err), http.StatusNotAcceptable)
+	}
+	if fontFaces.dir == "" {
+		// temp directory per process, removed on cleanup
+		if fontFaces.dir, err = os.MkdirTemp("", "imagor-fonts-"); err != nil {
+			return
+		}
+	}
+	face.Path = filepath.Join(fontFaces.dir, key+strings.ToLower(ext))
+	if err = os.WriteFile(face.Path, buf, 0644); err != nil {
+		return
+	}
+	if len(fontFaces.keys) >= maxFontFaces {
+		evicted := fontFaces.keys[0]
+		fontFaces.keys = fontFaces.keys[1:]
+		_ = os.Remove(fontFaces.faces[evicted].Path)
+		delete(fontFaces.faces, evicted)
+	}
+	fontFaces.faces[key] = face
+	fontFaces.keys = append(fontFaces.keys, key)
+	return
+}
+
+// cleanupFontFaces removes cached font faces and the temp directory
+func cleanupFontFaces() {
+	fontFaces.Lock()
+	defer fontFaces.Unlock()
+	if fontFaces.dir != "" {
+		_ = os.RemoveAll(fontFaces.dir)
+		fontFaces.dir = ""
+	}
+	fontFaces.faces = map[string]fontFace{}
+	fontFaces.keys = nil
+}
@@ -158,9 +158,16 @@ int set_gifsave_options(VipsOperation *operation, SaveParams *params) {
 
 int set_avifsave_options(VipsOperation *operation, SaveParams *params) {
   int ret = vips_object_set(
-      VIPS_OBJECT(operation), "compression", VIPS_FOREIGN_HEIF_COMPRESSION_AV1,
+      VIPS_OBJECT(operation), "strip", params->stripMetadata,
+      "compression", VIPS_FOREIGN_HEIF_COMPRESSION_AV1,
       "lossless", params->heifLossless, "speed", params->avifSpeed, NULL);
 
+  // subsample_mode available since libvips 8.13, set only if not auto
+  if (!ret && params->heifSubsample) {
+    ret = vips_object_set(VIPS_OBJECT(operation), "subsample_mode",
+                          params->heifSubsample, NULL);
+  }
+
   if (!ret && params->quality) {
     ret = vips_object_set(VIPS_OBJECT(operation), "Q", params->quality, NULL);
   }
@@ -181,6 +188,23 @@ int set_jp2ksave_options(VipsOperation *operation, SaveParams *params) {
   return ret;
 }
 
+// https://www.libvips.org/API/current/VipsForeignSave.html#vips-jxlsave-buffer
+int set_jxlsave_options(VipsOperation *operation, SaveParams *params) {
+  int ret = vips_object_set(VIPS_OBJECT(operation), "strip",
+                            params->stripMetadata, "lossless",
+                            params->jxlLossless, NULL);
+
+  if (!ret && params->jxlEffort >= 1 && params->jxlEffort <= 9) {
+    ret = vips_object_set(VIPS_OBJECT(operation), "effort", params->jxlEffort,
+                          NULL);
+  }
+  if (!ret && params->quality) {
+    ret = vips_object_set(VIPS_OBJECT(operation), "Q", params->quality, NULL);
+  }
+
+  return ret;
+}
+
 int save_to_buffer(SaveParams *params) {
   switch (params->outputFormat) {
     case JPEG:
@@ -203,6 +227,8 @@ int save_to_buffer(SaveParams *params) {
       return save_buffer("heifsave_buffer", params, set_avifsave_options);
     case JP2K:
       return save_buffer("jp2ksave_buffer", params, set_jp2ksave_options);
+    case JXL:
+      return save_buffer("jxlsave_buffer", params, set_jxlsave_options);
     default:
       g_warning("Unsupported output type given: %d", params->outputFormat);
   }
@@ -253,10 +279,14 @@ static SaveParams defaultSaveParams = {
     .tiffYRes = 1.0,
 
     .avifSpeed = 5,
+    .heifSubsample = 0,
 
     .jp2kLossless = FALSE,
     .jp2kTileHeight = 512,
-    .jp2kTileWidth = 512};
+    .jp2kTileWidth = 512,
+
+    .jxlLossless = FALSE,
+    .jxlEffort = 7};
 
 SaveParams create_save_params(ImageType outputFormat) {
   SaveParams params = defaultSaveParams;
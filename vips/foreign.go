@@ -128,6 +128,7 @@ type AvifExportParams struct {
 	Quality       int
 	Lossless      bool
 	Speed         int
+	SubsampleMode SubsampleMode
 }
 
 // NewAvifExportParams creates default values for an export of an AVIF image.
@@ -158,6 +159,23 @@ func NewJp2kExportParams() *Jp2kExportParams {
 	}
 }
 
+// JxlExportParams are options when exporting a JPEG XL to file or buffer.
+type JxlExportParams struct {
+	StripMetadata bool
+	Quality       int
+	Lossless      bool
+	Effort        int
+}
+
+// NewJxlExportParams creates default values for an export of a JPEG XL image.
+func NewJxlExportParams() *JxlExportParams {
+	return &JxlExportParams{
+		Quality:  75,
+		Lossless: false,
+		Effort:   7,
+	}
+}
+
 func vipsSaveJPEGToBuffer(in *C.VipsImage, params JpegExportParams) ([]byte, error) {
 	p := C.create_save_params(C.JPEG)
 	p.inputImage = in
@@ -230,9 +248,11 @@ func vipsSaveAVIFToBuffer(in *C.VipsImage, params AvifExportParams) ([]byte, err
 	p := C.create_save_params(C.AVIF)
 	p.inputImage = in
 	p.outputFormat = C.AVIF
+	p.stripMetadata = C.int(boolToInt(params.StripMetadata))
 	p.quality = C.int(params.Quality)
 	p.heifLossless = C.int(boolToInt(params.Lossless))
 	p.avifSpeed = C.int(params.Speed)
+	p.heifSubsample = C.int(params.SubsampleMode)
 
 	return vipsSaveToBuffer(p)
 }
@@ -250,6 +270,18 @@ func vipsSaveJP2KToBuffer(in *C.VipsImage, params Jp2kExportParams) ([]byte, err
 	return vipsSaveToBuffer(p)
 }
 
+func vipsSaveJXLToBuffer(in *C.VipsImage, params JxlExportParams) ([]byte, error) {
+	p := C.create_save_params(C.JXL)
+	p.inputImage = in
+	p.outputFormat = C.JXL
+	p.stripMetadata = C.int(boolToInt(params.StripMetadata))
+	p.quality = C.int(params.Quality)
+	p.jxlLossless = C.int(boolToInt(params.Lossless))
+	p.jxlEffort = C.int(params.Effort)
+
+	return vipsSaveToBuffer(p)
+}
+
 func vipsSaveGIFToBuffer(in *C.VipsImage, params GifExportParams) ([]byte, error) {
 	p := C.create_save_params(C.GIF)
 	p.inputImage = in
@@ -25,7 +25,8 @@ typedef enum types {
   HEIF,
   BMP,
   AVIF,
-  JP2K
+  JP2K,
+  JXL
 } ImageType;
 
 typedef struct SaveParams {
@@ -79,11 +80,16 @@ typedef struct SaveParams {
 
   // AVIF
   int avifSpeed;
+  int heifSubsample;
 
   // JPEG2000
   BOOL jp2kLossless;
   int jp2kTileWidth;
   int	jp2kTileHeight;
+
+  // JPEG XL
+  BOOL jxlLossless;
+  int jxlEffort;
 } SaveParams;
 
 SaveParams create_save_params(ImageType outputFormat);
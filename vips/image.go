@@ -188,6 +188,21 @@ func LoadImageFromMemory(buf []byte, width, height, bands int) (*Image, error) {
 	return ref, nil
 }
 
+// NewText renders text into a single band mask with Pango font description,
+// optional font file, wrapping width, line spacing and alignment
+func NewText(text, font, fontFile string, width, spacing int, align Align) (*Image, error) {
+	startupIfNeeded()
+
+	vipsImage, err := vipsText(text, font, fontFile, width, spacing, align)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := newImageRef(vipsImage, ImageTypeUnknown, nil)
+	log("vips", LogLevelDebug, fmt.Sprintf("created imageRef %p", ref))
+	return ref, nil
+}
+
 // Copy creates a new copy of the given image.
 func (r *Image) Copy() (*Image, error) {
 	out, err := vipsCopyImage(r.image)
@@ -307,6 +322,12 @@ func (r *Image) Exif() map[string]any {
 	return vipsImageGetExif(r.image)
 }
 
+// ICCProfile returns ICC profile data if exists
+func (r *Image) ICCProfile() []byte {
+	data, _ := vipsImageGetICCProfile(r.image)
+	return data
+}
+
 // ExportJpeg exports the image as JPEG to a buffer.
 func (r *Image) ExportJpeg(params *JpegExportParams) ([]byte, error) {
 	if params == nil {
@@ -419,6 +440,20 @@ func (r *Image) ExportJp2k(params *Jp2kExportParams) ([]byte, error) {
 	return buf, nil
 }
 
+// ExportJxl exports the image as JPEG XL to a buffer.
+func (r *Image) ExportJxl(params *JxlExportParams) ([]byte, error) {
+	if params == nil {
+		params = NewJxlExportParams()
+	}
+
+	buf, err := vipsSaveJXLToBuffer(r.image, *params)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
 // Composite composites the given overlay image on top of the associated image with provided blending mode.
 func (r *Image) Composite(overlay *Image, mode BlendMode, x, y int) error {
 	out, err := vipsComposite2(r.image, overlay.image, mode, x, y)
@@ -534,6 +569,27 @@ func (r *Image) Label(
 	return nil
 }
 
+// MaskRGBA converts single band mask to RGBA image of color,
+// using the mask multiplied by opacity as alpha
+func (r *Image) MaskRGBA(color *Color, opacity float64) error {
+	out, err := vipsMaskRGBA(r.image, color, opacity)
+	if err != nil {
+		return err
+	}
+	r.setImage(out)
+	return nil
+}
+
+// Rank does rank filtering of window size, e.g. index width*height-1 for maximum
+func (r *Image) Rank(width, height, index int) error {
+	out, err := vipsRank(r.image, width, height, index)
+	if err != nil {
+		return err
+	}
+	r.setImage(out)
+	return nil
+}
+
 // GaussianBlur blurs the image
 func (r *Image) GaussianBlur(sigma float64) error {
 	out, err := vipsGaussianBlur(r.image, sigma)
@@ -610,6 +666,11 @@ func (r *Image) GetPoint(x int, y int) ([]float64, error) {
 	return vipsGetPoint(r.image, n, x, y)
 }
 
+// Deviate returns the standard deviation of pixel values of the image
+func (r *Image) Deviate() (float64, error) {
+	return vipsDeviate(r.image)
+}
+
 // Thumbnail resizes the image to the given width and height.
 // crop decides algorithm vips uses to shrink and crop to fill target,
 func (r *Image) Thumbnail(width, height int, crop Interesting) error {
@@ -702,6 +763,17 @@ func (r *Image) Rotate(angle Angle) error {
 	return nil
 }
 
+// RotateAngle rotates the image clockwise by any angle in degrees,
+// enlarging the image to fit with transparent or black background
+func (r *Image) RotateAngle(angle float64) error {
+	out, err := vipsSimilarityRotate(r.image, angle)
+	if err != nil {
+		return err
+	}
+	r.setImage(out)
+	return nil
+}
+
 // Replicate repeats an image many times across and down
 func (r *Image) Replicate(across int, down int) error {
 	out, err := vipsReplicate(r.image, across, down)
@@ -712,6 +784,20 @@ func (r *Image) Replicate(across int, down int) error {
 	return nil
 }
 
+// ArrayJoin joins the image followed by images into a grid of across images per row
+func (r *Image) ArrayJoin(images []*Image, across int) error {
+	ins := []*C.VipsImage{r.image}
+	for _, img := range images {
+		ins = append(ins, img.image)
+	}
+	out, err := vipsArrayJoin(ins, across)
+	if err != nil {
+		return err
+	}
+	r.setImage(out)
+	return nil
+}
+
 // setImage resets the image for this image and frees the previous one
 func (r *Image) setImage(image *C.VipsImage) {
 	r.lock.Lock()
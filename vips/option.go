@@ -140,3 +140,10 @@ func WithMaxResolution(res int) Option {
 		}
 	}
 }
+
+// WithDetector with focal regions detector option, e.g. face detection for smart crop
+func WithDetector(detector Detector) Option {
+	return func(v *Processor) {
+		v.Detector = detector
+	}
+}
@@ -26,6 +26,7 @@ var imageTypeMap = map[string]ImageType{
 	"bmp":    ImageTypeBMP,
 	"avif":   ImageTypeAVIF,
 	"jp2":    ImageTypeJP2K,
+	"jxl":    ImageTypeJXL,
 }
 
 // Process implements imagor.Processor interface
@@ -48,6 +49,8 @@ func (v *Processor) Process(
 		page                  = 1
 		dpi                   = 0
 		focalRects            []focal
+		forwardVideo          bool
+		hasFrames             bool
 		err                   error
 	)
 	if p.Trim {
@@ -68,7 +71,12 @@ func (v *Processor) Process(
 		}
 		switch p.Name {
 		case "format":
-			if imageType, ok := imageTypeMap[p.Args]; ok {
+			if p.Args == "mp4" {
+				// animated gif forwarded for video encoding by next processor
+				forwardVideo = true
+				format = ImageTypeGIF
+			} else if imageType, ok := imageTypeMap[p.Args]; ok {
+				forwardVideo = false
 				format = supportedSaveFormat(imageType)
 				if !IsAnimationSupported(format) {
 					// no frames if export format not support animation
@@ -84,6 +92,9 @@ func (v *Processor) Process(
 		case "stretch":
 			stretch = true
 			break
+		case "frames":
+			hasFrames = true
+			break
 		case "upscale":
 			upscale = true
 			break
@@ -164,8 +175,11 @@ func (v *Processor) Process(
 			if p.Width > 0 && p.Height > 0 {
 				interest := InterestingNone
 				if p.Smart {
-					interest = InterestingAttention
-					thumbnail = true
+					if v.Detector == nil {
+						interest = InterestingAttention
+						thumbnail = true
+					}
+					// otherwise load without crop for focal regions detection
 				} else if (p.VAlign == imagorpath.VAlignTop && p.HAlign == "") ||
 					(p.HAlign == imagorpath.HAlignLeft && p.VAlign == "") {
 					interest = InterestingLow
@@ -231,6 +245,7 @@ func (v *Processor) Process(
 	}
 	var (
 		quality    int
+		enc        encoderOptions
 		origWidth  = float64(img.Width())
 		origHeight = float64(img.PageHeight())
 	)
@@ -241,6 +256,10 @@ func (v *Processor) Process(
 		} else {
 			format = img.Format()
 		}
+		if hasFrames && !IsAnimationSupported(format) {
+			// animation built from frames
+			format = ImageTypeGIF
+		}
 	}
 	if v.Debug {
 		v.Logger.Debug("image",
@@ -256,6 +275,25 @@ func (v *Processor) Process(
 		case "quality":
 			quality, _ = strconv.Atoi(p.Args)
 			break
+		case "lossless":
+			enc.Lossless = true
+			break
+		case "effort":
+			enc.Effort, _ = strconv.Atoi(p.Args)
+			break
+		case "subsampling":
+			enc.Subsampling = parseSubsampling(p.Args)
+			break
+		case "progressive":
+			enc.Progressive = true
+			break
+		case "palette":
+			enc.Palette = true
+			enc.Colors, _ = strconv.Atoi(p.Args)
+			break
+		case "strip_metadata":
+			enc.StripMetadata = true
+			break
 		case "autojpg":
 			format = ImageTypeJPEG
 			break
@@ -292,16 +330,24 @@ func (v *Processor) Process(
 			break
 		}
 	}
+	if p.Smart && len(focalRects) == 0 && !thumbnail && !p.FitIn && !stretch &&
+		p.Width > 0 && p.Height > 0 {
+		focalRects = v.detectFocalRects(ctx, img)
+	}
 	if err := v.process(ctx, img, p, load, thumbnail, stretch, upscale, focalRects); err != nil {
 		return nil, WrapErr(err)
 	}
 	if p.Meta {
 		// metadata without export
-		return imagor.NewBlobFromJsonMarshal(metadata(img, format, stripExif)), nil
+		meta := metadata(img, format, stripExif)
+		if err := v.analyze(ctx, img, p.Filters, meta); err != nil {
+			return nil, WrapErr(err)
+		}
+		return imagor.NewBlobFromJsonMarshal(meta), nil
 	}
 	format = supportedSaveFormat(format) // convert to supported export format
 	for {
-		buf, err := v.export(img, format, quality)
+		buf, err := v.export(img, format, quality, enc)
 		if err != nil {
 			return nil, WrapErr(err)
 		}
@@ -336,6 +382,9 @@ func (v *Processor) Process(
 		if typ, ok := ImageMimeTypes[format]; ok {
 			blob.SetContentType(typ)
 		}
+		if forwardVideo {
+			return blob, imagor.ErrForward{Params: p}
+		}
 		return blob, nil
 	}
 }
@@ -493,16 +542,19 @@ func (v *Processor) process(
 		if filter.Args != "" {
 			args = strings.Split(filter.Args, ",")
 		}
+		spanCtx, span := imagor.StartSpan(ctx, "vips.filter."+filter.Name)
+		span.SetAttribute("imagor.filter.args", filter.Args)
+		var err error
 		if fn := v.Filters[filter.Name]; fn != nil {
-			if err := fn(ctx, img, load, args...); err != nil {
-				return err
-			}
+			err = fn(spanCtx, img, load, args...)
 		} else if filter.Name == "fill" {
-			if err := v.fill(ctx, img, w, h,
+			err = v.fill(spanCtx, img, w, h,
 				p.PaddingLeft, p.PaddingTop, p.PaddingRight, p.PaddingBottom,
-				filter.Args); err != nil {
-				return err
-			}
+				filter.Args)
+		}
+		span.End(err)
+		if err != nil {
+			return err
 		}
 		if v.Debug {
 			v.Logger.Debug("filter",
@@ -515,14 +567,20 @@ func (v *Processor) process(
 
 // Metadata image attributes
 type Metadata struct {
-	Format      string         `json:"format"`
-	ContentType string         `json:"content_type"`
-	Width       int            `json:"width"`
-	Height      int            `json:"height"`
-	Orientation int            `json:"orientation"`
-	Pages       int            `json:"pages"`
-	Bands       int            `json:"bands"`
-	Exif        map[string]any `json:"exif"`
+	Format         string         `json:"format"`
+	ContentType    string         `json:"content_type"`
+	Width          int            `json:"width"`
+	Height         int            `json:"height"`
+	Orientation    int            `json:"orientation"`
+	Pages          int            `json:"pages"`
+	Bands          int            `json:"bands"`
+	Exif           map[string]any `json:"exif"`
+	PHash          string         `json:"phash,omitempty"`
+	DHash          string         `json:"dhash,omitempty"`
+	DominantColors []string       `json:"dominant_colors,omitempty"`
+	Blurhash       string         `json:"blurhash,omitempty"`
+	HasAlpha       *bool          `json:"has_alpha,omitempty"`
+	ICCProfile     string         `json:"icc_profile,omitempty"`
 }
 
 func metadata(img *Image, format ImageType, stripExif bool) *Metadata {
@@ -551,7 +609,7 @@ func metadata(img *Image, format ImageType, stripExif bool) *Metadata {
 
 func supportedSaveFormat(format ImageType) ImageType {
 	switch format {
-	case ImageTypePNG, ImageTypeWEBP, ImageTypeTIFF, ImageTypeGIF, ImageTypeAVIF, ImageTypeHEIF, ImageTypeJP2K:
+	case ImageTypePNG, ImageTypeWEBP, ImageTypeTIFF, ImageTypeGIF, ImageTypeAVIF, ImageTypeHEIF, ImageTypeJP2K, ImageTypeJXL:
 		if IsSaveSupported(format) {
 			return format
 		}
@@ -562,47 +620,138 @@ func supportedSaveFormat(format ImageType) ImageType {
 	return ImageTypeJPEG
 }
 
-func (v *Processor) export(image *Image, format ImageType, quality int) ([]byte, error) {
+// encoderOptions encoder tuning options from filters
+type encoderOptions struct {
+	Lossless      bool
+	Effort        int
+	Subsampling   SubsampleMode
+	Progressive   bool
+	Palette       bool
+	Colors        int
+	StripMetadata bool
+}
+
+// parseSubsampling parses chroma subsampling filter arg e.g. 444, 420
+func parseSubsampling(arg string) SubsampleMode {
+	switch strings.TrimSpace(arg) {
+	case "444", "4:4:4":
+		return VipsForeignSubsampleOff
+	case "420", "4:2:0":
+		return VipsForeignSubsampleOn
+	}
+	return VipsForeignSubsampleAuto
+}
+
+// paletteBitdepth maps number of palette colors to PNG bitdepth
+func paletteBitdepth(colors int) int {
+	switch {
+	case colors <= 0:
+		return 8
+	case colors <= 2:
+		return 1
+	case colors <= 4:
+		return 2
+	case colors <= 16:
+		return 4
+	}
+	return 8
+}
+
+// clampEffort clamps effort within the encoder maximum
+func clampEffort(effort, max int) int {
+	if effort > max {
+		return max
+	}
+	return effort
+}
+
+func (v *Processor) export(image *Image, format ImageType, quality int, enc encoderOptions) ([]byte, error) {
 	switch format {
 	case ImageTypePNG:
 		opts := NewPngExportParams()
+		opts.StripMetadata = enc.StripMetadata
+		opts.Interlace = enc.Progressive
+		if enc.Effort > 0 {
+			opts.Compression = clampEffort(enc.Effort, 9)
+		}
+		if enc.Palette {
+			opts.Palette = true
+			opts.Bitdepth = paletteBitdepth(enc.Colors)
+			if quality > 0 {
+				opts.Quality = quality
+			}
+		}
 		return image.ExportPng(opts)
 	case ImageTypeWEBP:
 		opts := NewWebpExportParams()
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		opts.StripMetadata = enc.StripMetadata
+		opts.Lossless = enc.Lossless
+		if enc.Effort > 0 {
+			opts.ReductionEffort = clampEffort(enc.Effort, 6)
+		}
 		return image.ExportWebp(opts)
 	case ImageTypeTIFF:
 		opts := NewTiffExportParams()
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		opts.StripMetadata = enc.StripMetadata
 		return image.ExportTiff(opts)
 	case ImageTypeGIF:
 		opts := NewGifExportParams()
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		opts.StripMetadata = enc.StripMetadata
+		if enc.Effort > 0 {
+			opts.Effort = clampEffort(enc.Effort, 10)
+		}
+		if enc.Colors > 0 {
+			opts.Bitdepth = paletteBitdepth(enc.Colors)
+		}
 		return image.ExportGIF(opts)
 	case ImageTypeAVIF:
 		opts := NewAvifExportParams()
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		opts.StripMetadata = enc.StripMetadata
+		opts.Lossless = enc.Lossless
+		opts.SubsampleMode = enc.Subsampling
+		if enc.Effort > 0 {
+			// libvips speed is the inverse of effort
+			opts.Speed = 9 - clampEffort(enc.Effort, 9)
+		}
 		return image.ExportAvif(opts)
 	case ImageTypeHEIF:
 		opts := NewHeifExportParams()
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		opts.Lossless = enc.Lossless
 		return image.ExportHeif(opts)
 	case ImageTypeJP2K:
 		opts := NewJp2kExportParams()
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		opts.Lossless = enc.Lossless
+		opts.SubsampleMode = enc.Subsampling
 		return image.ExportJp2k(opts)
+	case ImageTypeJXL:
+		opts := NewJxlExportParams()
+		if quality > 0 {
+			opts.Quality = quality
+		}
+		opts.StripMetadata = enc.StripMetadata
+		opts.Lossless = enc.Lossless
+		if enc.Effort > 0 {
+			opts.Effort = clampEffort(enc.Effort, 9)
+		}
+		return image.ExportJxl(opts)
 	default:
 		opts := NewJpegExportParams()
 		if v.MozJPEG {
@@ -617,6 +766,12 @@ func (v *Processor) export(image *Image, format ImageType, quality int) ([]byte,
 		if quality > 0 {
 			opts.Quality = quality
 		}
+		if enc.StripMetadata {
+			opts.StripMetadata = true
+		}
+		if enc.Subsampling != VipsForeignSubsampleAuto {
+			opts.SubsampleMode = enc.Subsampling
+		}
 		return image.ExportJpeg(opts)
 	}
 }
@@ -36,6 +36,8 @@ type Processor struct {
 	MaxResolution      int
 	MaxAnimationFrames int
 	MozJPEG            bool
+	Detector           Detector
+	DetectSize         int
 	Debug              bool
 
 	disableFilters map[string]bool
@@ -50,6 +52,7 @@ func NewProcessor(options ...Option) *Processor {
 		Concurrency:        1,
 		MaxFilterOps:       -1,
 		MaxAnimationFrames: -1,
+		DetectSize:         512,
 		Logger:             zap.NewNop(),
 		disableFilters:     map[string]bool{},
 	}
@@ -58,6 +61,8 @@ func NewProcessor(options ...Option) *Processor {
 		"round_corner":     roundCorner,
 		"rotate":           rotate,
 		"label":            label,
+		"text":             drawText,
+		"overlay_svg":      v.overlaySVG,
 		"grayscale":        grayscale,
 		"brightness":       brightness,
 		"background_color": backgroundColor,
@@ -72,6 +77,7 @@ func NewProcessor(options ...Option) *Processor {
 		"strip_exif":       stripExif,
 		"trim":             trim,
 		"set_frames":       setFrames,
+		"frames":           v.frames,
 		"padding":          v.padding,
 		"proportion":       proportion,
 	}
@@ -133,6 +139,7 @@ func (v *Processor) Shutdown(_ context.Context) error {
 	processorCount--
 	if processorCount == 0 {
 		Shutdown()
+		cleanupFontFaces()
 	}
 	return nil
 }
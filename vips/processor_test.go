@@ -2,7 +2,9 @@ package vips
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"image"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +16,7 @@ import (
 	"testing"
 
 	"github.com/cshum/imagor"
+	"github.com/cshum/imagor/ffmpeg"
 	"github.com/cshum/imagor/imagorpath"
 	"github.com/cshum/imagor/storage/filestorage"
 	"github.com/stretchr/testify/assert"
@@ -35,6 +38,17 @@ type test struct {
 	arm64Golden   bool
 }
 
+type testDetector struct {
+	Count  int
+	Bounds image.Rectangle
+}
+
+func (d *testDetector) Detect(_ context.Context, img image.Image) ([]image.Rectangle, error) {
+	d.Count++
+	d.Bounds = img.Bounds()
+	return []image.Rectangle{image.Rect(0, 0, 10, 10)}, nil
+}
+
 func TestProcessor(t *testing.T) {
 	v := NewProcessor(WithDebug(true))
 	require.NoError(t, v.Startup(context.Background()))
@@ -58,6 +72,9 @@ func TestProcessor(t *testing.T) {
 			{name: "export tiff", path: "filters:format(tiff):quality(70)/gopher-front.png"},
 			//{name: "export avif", path: "filters:format(avif):quality(70)/gopher-front.png", checkTypeOnly: true},
 			//{name: "export heif", path: "filters:format(heif):quality(70)/gopher-front.png", checkTypeOnly: true},
+			{name: "export webp lossless", path: "filters:format(webp):lossless():effort(6):strip_metadata()/gopher-front.png", checkTypeOnly: true},
+			{name: "export jpeg subsampling progressive", path: "filters:format(jpeg):subsampling(444):progressive():quality(90)/gopher-front.png", checkTypeOnly: true},
+			{name: "export png palette", path: "filters:format(png):palette(16):effort(9)/gopher-front.png", checkTypeOnly: true},
 		}, WithDebug(true), WithLogger(zap.NewExample()))
 	})
 	t.Run("meta", func(t *testing.T) {
@@ -69,6 +86,7 @@ func TestProcessor(t *testing.T) {
 			{name: "meta format no animate", path: "meta/fit-in/100x100/filters:format(jpg)/dancing-banana.gif"},
 			{name: "meta exif", path: "meta/Canon_40D.jpg"},
 			{name: "meta strip exif", path: "meta/filters:strip_exif()/Canon_40D.jpg"},
+			{name: "meta analyze", path: "meta/filters:phash():dhash():dominant_colors(3):blurhash():has_alpha():icc_profile()/gopher.png"},
 		}, WithDebug(true), WithLogger(zap.NewExample()))
 	})
 	t.Run("vips operations", func(t *testing.T) {
@@ -132,6 +150,10 @@ func TestProcessor(t *testing.T) {
 			{name: "watermark 2 bands", path: "filters:watermark(2bands.png,repeat,bottom,40,25,50)/demo1.jpg"},
 			{name: "watermark float", path: "fit-in/500x500/filters:fill(white):watermark(gopher.png,0.1,repeat,30,20,20):watermark(gopher.png,repeat,bottom,30,30,30):watermark(gopher-front.png,center,-0.1)/gopher.png"},
 			{name: "watermark align", path: "fit-in/500x500/filters:fill(white):watermark(gopher.png,left,top,30,20,20):watermark(gopher.png,right,center,30,30,30):watermark(gopher-front.png,-20,-10)/gopher.png"},
+			{name: "watermark position margin", path: "fit-in/500x300/filters:fill(white):watermark(gopher.png,top-left,5,30,20,20):watermark(gopher.png,bottom,5,0,20,20):watermark(gopher-front.png,bottom-right,3,0,20,20,-30,multiply)/gopher.png"},
+			{name: "watermark auto", path: "fit-in/500x500/filters:fill(white):watermark(gopher-front.png,auto,3,0,20,20)/demo1.jpg"},
+			{name: "watermark diagonal", path: "fit-in/500x500/filters:fill(white):watermark(gopher-front.png,diagonal,5,50,15,15)/gopher.png"},
+			{name: "watermark diagonal animated", path: "fit-in/200x150/filters:watermark(gopher-front.png,diagonal,0,0,30,30,30,screen)/dancing-banana.gif", arm64Golden: true},
 
 			{name: "original no animate", path: "filters:fill(white):format(jpeg)/dancing-banana.gif"},
 			{name: "original animated", path: "dancing-banana.gif"},
@@ -169,6 +191,12 @@ func TestProcessor(t *testing.T) {
 			{name: "watermark repeated animated", path: "fit-in/200x150/filters:fill(cyan):watermark(dancing-banana.gif,repeat,bottom,0,50,50)/dancing-banana.gif", arm64Golden: true},
 			{name: "animated fill round_corner", path: "filters:fill(cyan):round_corner(60)/dancing-banana.gif"},
 			{name: "label", path: "fit-in/300x200/10x10/filters:fill(yellow):label(IMAGOR,15,10,30,blue,30)/gopher-front.png", arm64Golden: true},
+			{name: "text", path: "fit-in/400x300/filters:fill(white):text(Hello%20imagor%0Asocial%20cards,center,20,Go-Bold.ttf,28,white,80p,center,4,2:black,2:3:2:black:50,10:navy:30)/gopher.png", arm64Golden: true},
+			{name: "text limits", path: "fit-in/300x200/filters:text(imagor,0,0,sans,100000,white,0,left,0,99999:black,99999:-99999:99999:black:50,99999:navy)/gopher.png", arm64Golden: true},
+			{name: "text system font", path: "fit-in/300x200/filters:text(imagor,-10,bottom,sans,24,yellow):text(imagor,10,top)/dancing-banana.gif", arm64Golden: true},
+			{name: "overlay svg", path: "fit-in/500x400/filters:overlay_svg(card.svg,center,-20,title=Hello%20%3Cimagor%3E,author=Gopher)/demo1.jpg"},
+			{name: "frames", path: "fit-in/200x150/filters:fill(white):frames(gopher.png,gopher-front.png;200)/demo1.jpg", arm64Golden: true},
+			{name: "frames animated", path: "fit-in/200x150/filters:frames(gopher.png,demo1.jpg):format(webp)/dancing-banana.gif", arm64Golden: true},
 			{name: "label top left", path: "fit-in/300x200/10x10/filters:fill(yellow):label(IMAGOR,left,top,30,red,30)/gopher-front.png", arm64Golden: true},
 			{name: "label right center", path: "fit-in/300x200/10x10/filters:fill(yellow):label(IMAGOR,right,center,30,red,30)/gopher-front.png", arm64Golden: true},
 			{name: "label center bottom", path: "fit-in/300x200/10x10/filters:fill(yellow):label(IMAGOR,center,bottom,30,red,30)/gopher-front.png", arm64Golden: true},
@@ -208,6 +236,15 @@ func TestProcessor(t *testing.T) {
 			{name: "watermark repeated animated", path: "fit-in/200x150/filters:fill(cyan):watermark(dancing-banana.gif,repeat,bottom,0,50,50)/dancing-banana.gif", arm64Golden: true},
 		}, WithDebug(true), WithDisableBlur(true), WithMaxAnimationFrames(3))
 	})
+	t.Run("detector", func(t *testing.T) {
+		var resultDir = filepath.Join(testDataDir, "golden/detector")
+		doGoldenTests(t, resultDir, []test{
+			{name: "smart crop detected", path: "100x100/smart/gopher.png"},
+			{name: "smart crop detected wide", path: "200x50/smart/gopher.png"},
+			{name: "smart crop detected animated", path: "100x50/smart/dancing-banana.gif"},
+			{name: "smart crop focal over detected", path: "100x100/smart/filters:focal(300x100:400x200)/gopher.png"},
+		}, WithDebug(true), WithDetector(&testDetector{}))
+	})
 	t.Run("disable filters", func(t *testing.T) {
 		var resultDir = filepath.Join(testDataDir, "golden/disable-filters")
 		doGoldenTests(t, resultDir, []test{
@@ -260,6 +297,77 @@ func TestProcessor(t *testing.T) {
 		assert.Equal(t, 406, w.Code)
 		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 	})
+	t.Run("meta analyze", func(t *testing.T) {
+		app := imagor.New(
+			imagor.WithLoaders(filestorage.New(testDataDir)),
+			imagor.WithUnsafe(true),
+			imagor.WithDebug(true),
+			imagor.WithLogger(zap.NewExample()),
+			imagor.WithProcessors(NewProcessor(WithDebug(true))),
+		)
+		require.NoError(t, app.Startup(context.Background()))
+		t.Cleanup(func() {
+			assert.NoError(t, app.Shutdown(context.Background()))
+		})
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+			"/unsafe/meta/filters:phash():dhash():dominant_colors(3):blurhash():has_alpha()/gopher.png", nil))
+		require.Equal(t, 200, w.Code)
+		var meta Metadata
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &meta))
+		assert.Regexp(t, "^[0-9a-f]{16}$", meta.PHash)
+		assert.Regexp(t, "^[0-9a-f]{16}$", meta.DHash)
+		require.Len(t, meta.DominantColors, 3)
+		for _, c := range meta.DominantColors {
+			assert.Regexp(t, "^#[0-9a-f]{6}$", c)
+		}
+		assert.NotEmpty(t, meta.Blurhash)
+		require.NotNil(t, meta.HasAlpha)
+		assert.True(t, *meta.HasAlpha)
+
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unsafe/meta/gopher.png", nil))
+		require.Equal(t, 200, w.Code)
+		for _, field := range []string{"phash", "dhash", "dominant_colors", "blurhash", "has_alpha"} {
+			assert.NotContains(t, w.Body.String(), `"`+field+`"`, "omitted unless requested")
+		}
+	})
+	t.Run("detector", func(t *testing.T) {
+		detector := &testDetector{}
+		app := imagor.New(
+			imagor.WithLoaders(filestorage.New(testDataDir)),
+			imagor.WithUnsafe(true),
+			imagor.WithDebug(true),
+			imagor.WithLogger(zap.NewExample()),
+			imagor.WithProcessors(NewProcessor(
+				WithDetector(detector),
+				WithDebug(true),
+			)),
+		)
+		require.NoError(t, app.Startup(context.Background()))
+		t.Cleanup(func() {
+			assert.NoError(t, app.Shutdown(context.Background()))
+		})
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "/unsafe/meta/100x100/smart/gopher.png", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Contains(t, w.Body.String(), `"width":100,"height":100`)
+		assert.Equal(t, 1, detector.Count)
+		assert.LessOrEqual(t, detector.Bounds.Dx(), 512)
+		assert.LessOrEqual(t, detector.Bounds.Dy(), 512)
+
+		for _, path := range []string{
+			"/unsafe/meta/100x100/gopher.png",
+			"/unsafe/meta/fit-in/100x100/smart/gopher.png",
+			"/unsafe/meta/100x100/smart/filters:focal(10x10:50x50)/gopher.png",
+		} {
+			w = httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, 200, w.Code)
+		}
+		assert.Equal(t, 1, detector.Count, "detect only on smart crop without focal")
+	})
 	t.Run("resolution exceeded", func(t *testing.T) {
 		app := imagor.New(
 			imagor.WithLoaders(filestorage.New(testDataDir)),
@@ -332,6 +440,33 @@ func TestProcessor(t *testing.T) {
 			http.MethodGet, "/unsafe/dancing-banana.gif", nil))
 		assert.Equal(t, 422, w.Code)
 	})
+	t.Run("resolution exceeded frames", func(t *testing.T) {
+		app := imagor.New(
+			imagor.WithLoaders(filestorage.New(testDataDir)),
+			imagor.WithUnsafe(true),
+			imagor.WithDebug(true),
+			imagor.WithLogger(zap.NewExample()),
+			imagor.WithProcessors(NewProcessor(
+				WithMaxResolution(300*300),
+				WithDebug(true),
+			)),
+		)
+		require.NoError(t, app.Startup(context.Background()))
+		t.Cleanup(func() {
+			assert.NoError(t, app.Shutdown(context.Background()))
+		})
+		// 100x100 frames within 300x300 resolution up to 9 frames
+		frames := strings.TrimSuffix(strings.Repeat("gopher-front.png,", 8), ",")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "/unsafe/100x100/filters:frames("+frames+")/gopher-front.png", nil))
+		assert.Equal(t, 200, w.Code)
+
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "/unsafe/100x100/filters:frames("+frames+",gopher-front.png)/gopher-front.png", nil))
+		assert.Equal(t, 422, w.Code)
+	})
 	t.Run("invalid BMP", func(t *testing.T) {
 		ctx := context.Background()
 		blob := imagor.NewBlobFromBytes([]byte("BMabcdasdfasdfasdfasdfasdfasdfasdfasdfasdfasdf"))
@@ -431,3 +566,65 @@ type loaderFunc func(r *http.Request, image string) (blob *imagor.Blob, err erro
 func (f loaderFunc) Get(r *http.Request, image string) (*imagor.Blob, error) {
 	return f(r, image)
 }
+
+func TestLoadFontFace(t *testing.T) {
+	defer cleanupFontFaces()
+	buf, err := os.ReadFile(filepath.Join(testDataDir, "Go-Bold.ttf"))
+	require.NoError(t, err)
+	face, err := loadFontFace(imagor.NewBlobFromBytes(buf), ".TTF")
+	require.NoError(t, err)
+	assert.Equal(t, "Go", face.Family)
+	assert.True(t, strings.HasSuffix(face.Path, ".ttf"))
+	assert.FileExists(t, face.Path)
+
+	face2, err := loadFontFace(imagor.NewBlobFromBytes(buf), ".ttf")
+	require.NoError(t, err)
+	assert.Equal(t, face, face2, "cached by content hash")
+	assert.Len(t, fontFaces.keys, 1)
+
+	_, err = loadFontFace(imagor.NewBlobFromBytes([]byte("abc")), ".ttf")
+	assert.Error(t, err)
+
+	cleanupFontFaces()
+	assert.NoFileExists(t, face.Path, "font files removed on cleanup")
+	assert.Empty(t, fontFaces.faces)
+
+	evicted := filepath.Join(t.TempDir(), "evicted.ttf")
+	require.NoError(t, os.WriteFile(evicted, buf, 0644))
+	for i := 0; i < maxFontFaces; i++ {
+		fontFaces.faces[fmt.Sprint(i)] = fontFace{Path: evicted}
+		fontFaces.keys = append(fontFaces.keys, fmt.Sprint(i))
+	}
+	_, err = loadFontFace(imagor.NewBlobFromBytes(buf), ".ttf")
+	require.NoError(t, err)
+	assert.Len(t, fontFaces.keys, maxFontFaces)
+	assert.NotContains(t, fontFaces.faces, "0")
+	assert.NoFileExists(t, evicted, "evicted font file removed")
+}
+
+func TestVideoEncoderChain(t *testing.T) {
+	// fake ffmpeg writes MP4 header followed by its input to the output file of last argument
+	binary := filepath.Join(t.TempDir(), "ffmpeg")
+	require.NoError(t, os.WriteFile(binary, []byte(
+		"#!/bin/sh\nfor last; do true; done\n(printf '\\000\\000\\000\\040ftypisom'; cat) > \"$last\"\n"), 0755))
+	ff := ffmpeg.NewProcessor(ffmpeg.WithBinary(binary))
+	app := imagor.New(
+		imagor.WithUnsafe(true),
+		imagor.WithLoaders(filestorage.New(testDataDir)),
+		imagor.WithProcessors(ff, NewProcessor(WithDebug(true)), ff.Encoder()),
+	)
+	require.NoError(t, app.Startup(context.Background()))
+	t.Cleanup(func() {
+		assert.NoError(t, app.Shutdown(context.Background()))
+	})
+	for _, image := range []string{"dancing-banana.gif", "demo3.webp"} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "/unsafe/fit-in/100x100/filters:format(mp4)/"+image, nil))
+		assert.Equal(t, 200, w.Code, image)
+		assert.Equal(t, "video/mp4", w.Header().Get("Content-Type"), image)
+		body := w.Body.Bytes()
+		require.Greater(t, len(body), 16, image)
+		assert.Equal(t, "GIF8", string(body[12:16]), "animation forwarded by vips as gif: %s", image)
+	}
+}
@@ -22,6 +22,7 @@ const (
 	ImageTypeBMP
 	ImageTypeAVIF
 	ImageTypeJP2K
+	ImageTypeJXL
 )
 
 // IsSaveSupported indicates if image type supports save
@@ -70,6 +71,9 @@ func vipsDetermineImageTypeFromMetaLoader(in *C.VipsImage) ImageType {
 			if strings.HasPrefix(vipsLoader, "jp2k") {
 				return ImageTypeJP2K
 			}
+			if strings.HasPrefix(vipsLoader, "jxl") {
+				return ImageTypeJXL
+			}
 			if strings.HasPrefix(vipsLoader, "magick") {
 				return ImageTypeMagick
 			}
@@ -92,6 +96,7 @@ var ImageTypes = map[ImageType]string{
 	ImageTypeBMP:    "bmp",
 	ImageTypeAVIF:   "avif",
 	ImageTypeJP2K:   "jp2k",
+	ImageTypeJXL:    "jxl",
 }
 
 // ImageMimeTypes map the various image types to its mime type representation
@@ -107,6 +112,7 @@ var ImageMimeTypes = map[ImageType]string{
 	ImageTypeBMP:  "image/bmp",
 	ImageTypeAVIF: "image/avif",
 	ImageTypeJP2K: "image/jp2",
+	ImageTypeJXL:  "image/jxl",
 }
 
 // Color represents an RGB
@@ -242,6 +242,15 @@ int rotate_image(VipsImage *in, VipsImage **out, VipsAngle angle) {
   return vips_rot(in, out, angle, NULL);
 }
 
+int similarity_rotate_image(VipsImage *in, VipsImage **out, double angle) {
+  double background[4] = {0, 0, 0, 0};
+  int n = in->Bands > 4 ? 4 : in->Bands;
+  VipsArrayDouble *vipsBackground = vips_array_double_new(background, n);
+  int code = vips_similarity(in, out, "angle", angle, "background", vipsBackground, NULL);
+  vips_area_unref(VIPS_AREA(vipsBackground));
+  return code;
+}
+
 int rotate_image_multi_page(VipsImage *in, VipsImage **out, VipsAngle angle) {
   VipsObject *base = VIPS_OBJECT(vips_image_new());
   int page_height = vips_image_get_page_height(in);
@@ -422,6 +431,10 @@ int find_trim(VipsImage *in, int *left, int *top, int *width, int *height,
   return 0;
 }
 
+int deviate_image(VipsImage *in, double *out) {
+  return vips_deviate(in, out, NULL);
+}
+
 int getpoint(VipsImage *in, double **vector, int n, int x, int y) {
   return vips_getpoint(in, vector, &n, x, y, NULL);
 }
@@ -430,6 +443,43 @@ int to_colorspace(VipsImage *in, VipsImage **out, VipsInterpretation space) {
   return vips_colourspace(in, out, space, NULL);
 }
 
+int text_image(VipsImage **out, const char *text, const char *font,
+               const char *fontfile, int width, int spacing, VipsAlign align) {
+  if (fontfile != NULL && fontfile[0] != '\0') {
+    return vips_text(out, text, "font", font, "fontfile", fontfile,
+                     "width", width, "spacing", spacing, "align", align,
+                     "dpi", 72, NULL);
+  }
+  return vips_text(out, text, "font", font,
+                   "width", width, "spacing", spacing, "align", align,
+                   "dpi", 72, NULL);
+}
+
+int mask_rgba_image(VipsImage *in, VipsImage **out,
+                    double r, double g, double b, double opacity) {
+  double zeros[3] = {0, 0, 0};
+  double color[3] = {r, g, b};
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **)vips_object_local_array(VIPS_OBJECT(base), 3);
+  if (vips_linear(in, &t[0], zeros, color, 3, "uchar", TRUE, NULL) ||
+      vips_linear1(in, &t[1], opacity, 0.0, "uchar", TRUE, NULL) ||
+      vips_bandjoin2(t[0], t[1], &t[2], NULL) ||
+      vips_copy(t[2], out, "interpretation", VIPS_INTERPRETATION_sRGB, NULL)) {
+    g_object_unref(base);
+    return 1;
+  }
+  g_object_unref(base);
+  return 0;
+}
+
+int arrayjoin_images(VipsImage **in, VipsImage **out, int n, int across) {
+  return vips_arrayjoin(in, out, n, "across", across, NULL);
+}
+
+int rank_image(VipsImage *in, VipsImage **out, int width, int height, int index) {
+  return vips_rank(in, out, width, height, index, NULL);
+}
+
 int gaussian_blur_image(VipsImage *in, VipsImage **out, double sigma) {
   return vips_gaussblur(in, out, sigma, NULL);
 }
@@ -439,6 +489,13 @@ int sharpen_image(VipsImage *in, VipsImage **out, double sigma, double x1,
   return vips_sharpen(in, out, "sigma", sigma, "x1", x1, "m2", m2, NULL);
 }
 
+int get_icc_profile(VipsImage *in, const void **data, size_t *length) {
+  if (vips_image_get_typeof(in, VIPS_META_ICC_NAME) == 0) {
+    return 1;
+  }
+  return vips_image_get_blob(in, VIPS_META_ICC_NAME, data, length);
+}
+
 gboolean remove_icc_profile(VipsImage *in) {
   return vips_image_remove(in, VIPS_META_ICC_NAME);
 }
@@ -211,6 +211,17 @@ func vipsExtractAreaMultiPage(in *C.VipsImage, left, top, width, height int) (*C
 	return out, nil
 }
 
+// https://libvips.github.io/libvips/API/current/libvips-resample.html#vips-similarity
+func vipsSimilarityRotate(in *C.VipsImage, angle float64) (*C.VipsImage, error) {
+	var out *C.VipsImage
+
+	if err := C.similarity_rotate_image(in, &out, C.double(angle)); err != 0 {
+		return nil, handleImageError(out)
+	}
+
+	return out, nil
+}
+
 // https://libvips.github.io/libvips/API/current/libvips-conversion.html#vips-rot
 func vipsRotate(in *C.VipsImage, angle Angle) (*C.VipsImage, error) {
 	var out *C.VipsImage
@@ -321,6 +332,17 @@ func vipsFindTrim(in *C.VipsImage, threshold float64, x, y int) (int, int, int,
 	return int(left), int(top), int(width), int(height), nil
 }
 
+// https://libvips.github.io/libvips/API/current/libvips-arithmetic.html#vips-deviate
+func vipsDeviate(in *C.VipsImage) (float64, error) {
+	var out C.double
+
+	if err := C.deviate_image(in, &out); err != 0 {
+		return 0, handleVipsError()
+	}
+
+	return float64(out), nil
+}
+
 // https://libvips.github.io/libvips/API/current/libvips-arithmetic.html#vips-getpoint
 func vipsGetPoint(in *C.VipsImage, n int, x int, y int) ([]float64, error) {
 	var out *C.double
@@ -347,6 +369,57 @@ func vipsToColorSpace(in *C.VipsImage, interpretation Interpretation) (*C.VipsIm
 	return out, nil
 }
 
+// https://libvips.github.io/libvips/API/current/libvips-create.html#vips-text
+func vipsText(text, font, fontFile string, width, spacing int, align Align) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	cText := C.CString(text)
+	defer freeCString(cText)
+	cFont := C.CString(font)
+	defer freeCString(cFont)
+	cFontFile := C.CString(fontFile)
+	defer freeCString(cFontFile)
+
+	if err := C.text_image(&out, cText, cFont, cFontFile,
+		C.int(width), C.int(spacing), C.VipsAlign(align)); err != 0 {
+		return nil, handleImageError(out)
+	}
+
+	return out, nil
+}
+
+func vipsMaskRGBA(in *C.VipsImage, color *Color, opacity float64) (*C.VipsImage, error) {
+	var out *C.VipsImage
+
+	if err := C.mask_rgba_image(in, &out,
+		C.double(color.R), C.double(color.G), C.double(color.B), C.double(opacity)); err != 0 {
+		return nil, handleImageError(out)
+	}
+
+	return out, nil
+}
+
+// https://libvips.github.io/libvips/API/current/libvips-conversion.html#vips-arrayjoin
+func vipsArrayJoin(ins []*C.VipsImage, across int) (*C.VipsImage, error) {
+	var out *C.VipsImage
+
+	if err := C.arrayjoin_images(&ins[0], &out, C.int(len(ins)), C.int(across)); err != 0 {
+		return nil, handleImageError(out)
+	}
+
+	return out, nil
+}
+
+// https://libvips.github.io/libvips/API/current/libvips-morphology.html#vips-rank
+func vipsRank(in *C.VipsImage, width, height, index int) (*C.VipsImage, error) {
+	var out *C.VipsImage
+
+	if err := C.rank_image(in, &out, C.int(width), C.int(height), C.int(index)); err != 0 {
+		return nil, handleImageError(out)
+	}
+
+	return out, nil
+}
+
 // https://libvips.github.io/libvips/API/current/libvips-convolution.html#vips-gaussblur
 func vipsGaussianBlur(in *C.VipsImage, sigma float64) (*C.VipsImage, error) {
 	var out *C.VipsImage
@@ -406,6 +479,15 @@ func vipsImageGetMetaLoader(in *C.VipsImage) (string, bool) {
 	return C.GoString(out), code == 0
 }
 
+func vipsImageGetICCProfile(in *C.VipsImage) ([]byte, bool) {
+	var data unsafe.Pointer
+	var length C.size_t
+	if int(C.get_icc_profile(in, &data, &length)) != 0 {
+		return nil, false
+	}
+	return C.GoBytes(data, C.int(length)), true
+}
+
 func vipsImageSetDelay(in *C.VipsImage, data []C.int) error {
 	if n := len(data); n > 0 {
 		C.set_image_delay(in, &data[0], C.int(n))
@@ -55,6 +55,7 @@ int extract_area_multi_page(VipsImage *in, VipsImage **out, int left, int top,
 
 int rotate_image(VipsImage *in, VipsImage **out, VipsAngle angle);
 int rotate_image_multi_page(VipsImage *in, VipsImage **out, VipsAngle angle);
+int similarity_rotate_image(VipsImage *in, VipsImage **out, double angle);
 int flatten_image(VipsImage *in, VipsImage **out, double r, double g, double b);
 int label_image(VipsImage *in, VipsImage **out,
           const char *text, const char *font,
@@ -75,13 +76,21 @@ int linear(VipsImage *in, VipsImage **out, double *a, double *b, int n);
 int find_trim(VipsImage *in, int *left, int *top, int *width, int *height,
   double threshold, int x, int y);
 int getpoint(VipsImage *in, double **vector, int n, int x, int y);
+int deviate_image(VipsImage *in, double *out);
 
 int to_colorspace(VipsImage *in, VipsImage **out, VipsInterpretation space);
 
+int text_image(VipsImage **out, const char *text, const char *font,
+               const char *fontfile, int width, int spacing, VipsAlign align);
+int mask_rgba_image(VipsImage *in, VipsImage **out,
+                    double r, double g, double b, double opacity);
+int arrayjoin_images(VipsImage **in, VipsImage **out, int n, int across);
+int rank_image(VipsImage *in, VipsImage **out, int width, int height, int index);
 int gaussian_blur_image(VipsImage *in, VipsImage **out, double sigma);
 int sharpen_image(VipsImage *in, VipsImage **out, double sigma, double x1,
                   double m2);
 
+int get_icc_profile(VipsImage *in, const void **data, size_t *length);
 int remove_icc_profile(VipsImage *in);
 
 int get_meta_orientation(VipsImage *in);